@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+// policyFlags collects a restart policy from a JSON file and command flags.
+type policyFlags struct {
+	file        string
+	mode        string
+	exitCodes   []int
+	maxRestarts int
+	window      time.Duration
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	jitter      float64
+}
+
+func (f *policyFlags) addFlags(cmd *cobra.Command) {
+	defaults := policy.Default()
+	cmd.Flags().StringVarP(&f.file, "policy-file", "", "", "JSON file with the restart policy")
+	cmd.Flags().StringVarP(&f.mode, "restart", "", string(defaults.Mode), "Restart policy: always, on-failure, unless-stopped or never")
+	cmd.Flags().IntSliceVarP(&f.exitCodes, "exit-codes", "", nil, "Exit codes that trigger an on-failure restart (default: any non-zero)")
+	cmd.Flags().IntVarP(&f.maxRestarts, "max-restarts", "", defaults.MaxAttempts, "Maximum restarts allowed within the window (0: unlimited)")
+	cmd.Flags().DurationVarP(&f.window, "window", "", defaults.Window.Duration(), "Time window for --max-restarts")
+	cmd.Flags().DurationVarP(&f.backoff, "backoff", "", defaults.InitialBackoff.Duration(), "Initial delay before restarting")
+	cmd.Flags().DurationVarP(&f.maxBackoff, "max-backoff", "", defaults.MaxBackoff.Duration(), "Maximum delay between restarts")
+	cmd.Flags().Float64VarP(&f.jitter, "jitter", "", defaults.Jitter, "Random fraction (0-1) added to each delay")
+}
+
+// resolve returns the configured policy. Flags given explicitly override the
+// policy file.
+func (f *policyFlags) resolve(cmd *cobra.Command) (policy.Policy, error) {
+	p := policy.Default()
+	if f.file != "" {
+		loaded, err := policy.LoadFile(f.file)
+		if err != nil {
+			return policy.Policy{}, err
+		}
+		p = loaded
+	}
+	flags := cmd.Flags()
+	if f.file == "" || flags.Changed("restart") {
+		p.Mode = policy.Mode(f.mode)
+	}
+	if f.file == "" || flags.Changed("exit-codes") {
+		p.ExitCodes = f.exitCodes
+	}
+	if f.file == "" || flags.Changed("max-restarts") {
+		p.MaxAttempts = f.maxRestarts
+	}
+	if f.file == "" || flags.Changed("window") {
+		p.Window = policy.Duration(f.window)
+	}
+	if f.file == "" || flags.Changed("backoff") {
+		p.InitialBackoff = policy.Duration(f.backoff)
+	}
+	if f.file == "" || flags.Changed("max-backoff") {
+		p.MaxBackoff = policy.Duration(f.maxBackoff)
+	}
+	if f.file == "" || flags.Changed("jitter") {
+		p.Jitter = f.jitter
+	}
+	if err := p.Validate(); err != nil {
+		return policy.Policy{}, err
+	}
+	return p, nil
+}
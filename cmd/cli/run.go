@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/supervisor"
+	gl "github.com/rafa-mori/selfrestart/logger"
+	"github.com/spf13/cobra"
+)
+
+func runCommand() *cobra.Command {
+	var policyOpts policyFlags
+	var stopTimeout time.Duration
+
+	var runCmd = &cobra.Command{
+		Use: "run [flags] -- <command> [args...]",
+		Annotations: GetDescriptions([]string{
+			"Run a command under supervision.",
+			"This command keeps a child process alive, restarting it according to a restart policy with backoff. Send SIGUSR1 to restart the child gracefully.",
+		}, false),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			restartPolicy, err := policyOpts.resolve(cmd)
+			if err != nil {
+				gl.Log("error", err.Error())
+				os.Exit(1)
+			}
+
+			sup := supervisor.New(supervisor.Config{
+				Command:     args,
+				Policy:      restartPolicy,
+				StopTimeout: stopTimeout,
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, syscall.SIGUSR1, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGUSR2)
+			defer signal.Stop(sigCh)
+			go func() {
+				for sig := range sigCh {
+					switch sig {
+					case syscall.SIGUSR1:
+						sup.Restart()
+					case syscall.SIGINT, syscall.SIGTERM:
+						gl.Log("info", fmt.Sprintf("Received %v, stopping child", sig))
+						cancel()
+					default:
+						if err := sup.Signal(sig); err != nil {
+							gl.Log("warn", fmt.Sprintf("Could not forward %v: %v", sig, err))
+						}
+					}
+				}
+			}()
+
+			gl.Log("info", fmt.Sprintf("Supervisor PID: %d (send SIGUSR1 to restart the child)", os.Getpid()))
+			if err := sup.Run(ctx); err != nil {
+				gl.Log("error", fmt.Sprintf("Supervisor stopped: %v", err))
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+					os.Exit(exitErr.ExitCode())
+				}
+				os.Exit(1)
+			}
+		},
+	}
+
+	runCmd.Flags().SetInterspersed(false)
+	policyOpts.addFlags(runCmd)
+	runCmd.Flags().DurationVarP(&stopTimeout, "stop-timeout", "", supervisor.DefaultStopTimeout, "Time the child gets to exit after SIGTERM before it is killed")
+
+	return runCmd
+}
@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,13 +18,20 @@ func ServiceCmdList() []*cobra.Command {
 		startCommand(),
 		restartCommand(),
 		statusCommand(),
+		stopCommand(),
 		checkCommand(),
+		updateCommand(),
+		runCommand(),
+		serviceCommand(),
 	}
 }
 
 func startCommand() *cobra.Command {
 	var debug bool
 	var daemon bool
+	var pidFile string
+	var service string
+	var readyTimeout time.Duration
 
 	var startCmd = &cobra.Command{
 		Use: "start",
@@ -50,17 +59,60 @@ func startCommand() *cobra.Command {
 
 			if daemon {
 				gl.Log("info", "Starting in daemon mode...")
-				// Setup signal handling for restart
 				gl.Log("info", "Send SIGUSR1 to restart: kill -USR1 " + fmt.Sprintf("%d", sr.GetCurrentPID()))
 			}
 
 			gl.Log("success", "SelfRestart service started successfully")
-			
+
 			if daemon {
-				// Keep running
+				if pidFile == "" {
+					pidFile = selfrestart.PIDFilePath(service)
+				}
+				// Confirma a prontidão antes de disputar o PID file, que o
+				// processo anterior só libera depois de receber a confirmação
+				if err := selfrestart.Ready(); err != nil {
+					gl.Log("warn", fmt.Sprintf("Failed to report readiness: %v", err))
+				}
+				pf, err := sr.AcquirePIDFile(pidFile)
+				if err != nil {
+					gl.Log("error", fmt.Sprintf("Refusing to start: %v", err))
+					os.Exit(1)
+				}
+				gl.Log("info", fmt.Sprintf("PID file: %s", pf.Path()))
+
+				if interval, ok := selfrestart.StartWatchdog(context.Background()); ok {
+					gl.Log("info", fmt.Sprintf("systemd watchdog enabled (every %s)", interval))
+				}
+
+				var signalOpts selfrestart.SignalOptions
+				if readyTimeout > 0 {
+					signalOpts.RestartOptions = []selfrestart.RestartOption{selfrestart.WithReadiness(readyTimeout)}
+				}
+				events := sr.WatchSignals(context.Background(), signalOpts)
+				ticker := time.NewTicker(5 * time.Second)
+				defer ticker.Stop()
 				for {
-					time.Sleep(5 * time.Second)
-					gl.Log("debug", "Service running...")
+					select {
+					case ev, ok := <-events:
+						if !ok {
+							return
+						}
+						switch ev.Type {
+						case selfrestart.EventRestarted:
+							gl.Log("success", "Restart handed off, exiting current process")
+							os.Exit(0)
+						case selfrestart.EventRestartFailed:
+							gl.Log("error", fmt.Sprintf("Restart failed: %v", ev.Err))
+						case selfrestart.EventTerminated:
+							if err := pf.Release(); err != nil {
+								gl.Log("warn", fmt.Sprintf("Failed to remove PID file: %v", err))
+							}
+							gl.Log("info", "Service stopped")
+							os.Exit(0)
+						}
+					case <-ticker.C:
+						gl.Log("debug", "Service running...")
+					}
 				}
 			}
 		},
@@ -68,13 +120,17 @@ func startCommand() *cobra.Command {
 
 	startCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable debug mode")
 	startCmd.Flags().BoolVarP(&daemon, "daemon", "", false, "Run as daemon")
+	startCmd.Flags().StringVarP(&pidFile, "pidfile", "", "", "PID file written in daemon mode (default: derived from --service)")
+	startCmd.Flags().StringVarP(&service, "service", "s", "selfrestart", "Service name used for the default PID file")
+	startCmd.Flags().DurationVarP(&readyTimeout, "ready-timeout", "", 0, "On restart, wait this long for the new process to report readiness (0 disables)")
 
 	return startCmd
 }
 
 func restartCommand() *cobra.Command {
 	var wait bool
-	var pidFlag int
+	var waitTimeout time.Duration
+	var target pidTarget
 
 	var restartCmd = &cobra.Command{
 		Use: "restart",
@@ -85,45 +141,63 @@ func restartCommand() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			sr := selfrestart.New()
 
-			var targetPID int
-			if pidFlag > 0 {
-				targetPID = pidFlag
-				gl.Log("info", fmt.Sprintf("Attempting to restart process with PID: %d", targetPID))
-				
-				// Send SIGUSR1 signal to the target process
-				if err := syscall.Kill(targetPID, syscall.SIGUSR1); err != nil {
-					gl.Log("error", fmt.Sprintf("Failed to send restart signal to PID %d: %v", targetPID, err))
+			targetPIDs, err := target.resolveAll(context.Background(), sr)
+			if err != nil {
+				gl.Log("error", fmt.Sprintf("Failed to resolve %s: %v", target.describe(), err))
+				os.Exit(1)
+			}
+			if len(targetPIDs) > 0 {
+				failed := false
+				for _, pid := range targetPIDs {
+					if err := signalRestart(pid); err != nil {
+						gl.Log("error", err.Error())
+						failed = true
+					}
+				}
+				if failed {
 					os.Exit(1)
 				}
-				gl.Log("success", fmt.Sprintf("Restart signal sent to PID %d", targetPID))
 			} else {
-				targetPID = sr.GetCurrentPID()
+				targetPID := sr.GetCurrentPID()
 				gl.Log("info", fmt.Sprintf("Restarting current process (PID: %d)", targetPID))
-				
-				if err := sr.Restart(); err != nil {
+
+				var opts []selfrestart.RestartOption
+				if wait {
+					gl.Log("info", "Waiting for the new process to report readiness...")
+					opts = append(opts, selfrestart.WithReadiness(waitTimeout))
+				}
+				if err := sr.Restart(opts...); err != nil {
 					gl.Log("error", fmt.Sprintf("Failed to restart: %v", err))
 					os.Exit(1)
 				}
-				
-				if wait {
-					gl.Log("info", "Waiting for restart to complete...")
-					time.Sleep(2 * time.Second)
-				}
-				
+
 				gl.Log("success", "Process restart initiated")
 				os.Exit(0)
 			}
 		},
 	}
 
-	restartCmd.Flags().BoolVarP(&wait, "wait", "w", false, "Wait for restart to complete")
-	restartCmd.Flags().IntVarP(&pidFlag, "pid", "p", 0, "PID of process to restart")
+	restartCmd.Flags().BoolVarP(&wait, "wait", "w", false, "Wait for the new process to report readiness")
+	restartCmd.Flags().DurationVarP(&waitTimeout, "wait-timeout", "", selfrestart.DefaultReadyTimeout, "How long --wait waits for readiness")
+	target.addFlags(restartCmd, "PID of process to restart")
+	target.addDiscoveryFlags(restartCmd)
 
 	return restartCmd
 }
 
+// signalRestart asks the process with the given PID to restart itself by
+// sending SIGUSR1, which WatchSignals handles by default.
+func signalRestart(pid int) error {
+	gl.Log("info", fmt.Sprintf("Attempting to restart process with PID: %d", pid))
+	if err := syscall.Kill(pid, syscall.SIGUSR1); err != nil {
+		return fmt.Errorf("failed to send restart signal to PID %d: %v", pid, err)
+	}
+	gl.Log("success", fmt.Sprintf("Restart signal sent to PID %d", pid))
+	return nil
+}
+
 func statusCommand() *cobra.Command {
-	var pidFlag int
+	var target pidTarget
 
 	var statusCmd = &cobra.Command{
 		Use: "status",
@@ -134,25 +208,19 @@ func statusCommand() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			sr := selfrestart.New()
 
-			var targetPID int
-			if pidFlag > 0 {
-				targetPID = pidFlag
-			} else {
-				targetPID = sr.GetCurrentPID()
-			}
-
-			gl.Log("info", fmt.Sprintf("Checking status of PID: %d", targetPID))
-
-			running, err := sr.IsProcessRunning(targetPID)
+			targetPIDs, err := target.resolveAll(context.Background(), sr)
 			if err != nil {
-				gl.Log("error", fmt.Sprintf("Error checking process status: %v", err))
+				gl.Log("error", fmt.Sprintf("Failed to resolve %s: %v", target.describe(), err))
 				os.Exit(1)
 			}
-
-			if running {
-				gl.Log("success", fmt.Sprintf("Process %d is running", targetPID))
-			} else {
-				gl.Log("warn", fmt.Sprintf("Process %d is not running", targetPID))
+			if len(targetPIDs) == 0 {
+				targetPIDs = []int{sr.GetCurrentPID()}
+			}
+			for _, pid := range targetPIDs {
+				if err := logStatus(sr, pid); err != nil {
+					gl.Log("error", fmt.Sprintf("Error checking process status: %v", err))
+					os.Exit(1)
+				}
 			}
 
 			platformInfo := sr.GetPlatformInfo()
@@ -160,12 +228,67 @@ func statusCommand() *cobra.Command {
 		},
 	}
 
-	statusCmd.Flags().IntVarP(&pidFlag, "pid", "p", 0, "PID to check (default: current process)")
+	target.addFlags(statusCmd, "PID to check (default: current process)")
+	target.addDiscoveryFlags(statusCmd)
 
 	return statusCmd
 }
 
+// logStatus prints whether the process is running, its details and the
+// outcome of its last restart.
+func logStatus(sr *selfrestart.SelfRestart, pid int) error {
+	gl.Log("info", fmt.Sprintf("Checking status of PID: %d", pid))
+
+	running, err := sr.IsProcessRunning(pid)
+	if err != nil {
+		return err
+	}
+
+	if running {
+		gl.Log("success", fmt.Sprintf("Process %d is running", pid))
+		if info, err := sr.Inspect(pid); err == nil {
+			logProcessInfo(info)
+		} else {
+			gl.Log("debug", fmt.Sprintf("Could not inspect process %d: %v", pid, err))
+		}
+	} else {
+		gl.Log("warn", fmt.Sprintf("Process %d is not running", pid))
+	}
+
+	if binPath, err := sr.GetExecutablePath(pid); err == nil {
+		if outcome, err := selfrestart.LastRestartOutcome(binPath); err == nil {
+			gl.Log("info", fmt.Sprintf("Last restart of %s: %s (old PID %d, new PID %d) at %s",
+				binPath, outcome.Result, outcome.OldPID, outcome.NewPID, outcome.Time.Format(time.RFC3339)))
+			if outcome.Reason != "" {
+				gl.Log("info", fmt.Sprintf("Reason: %s", outcome.Reason))
+			}
+		}
+	}
+	return nil
+}
+
+// logProcessInfo prints the details of a process found by Inspect, skipping
+// the ones that could not be read.
+func logProcessInfo(info selfrestart.ProcessInfo) {
+	if info.Executable != "" {
+		gl.Log("info", fmt.Sprintf("Executable: %s", info.Executable))
+	}
+	if len(info.Args) > 0 {
+		gl.Log("info", fmt.Sprintf("Command line: %s", strings.Join(info.Args, " ")))
+	}
+	gl.Log("info", fmt.Sprintf("State: %s, parent PID: %d", info.State, info.PPID))
+	if !info.StartTime.IsZero() {
+		gl.Log("info", fmt.Sprintf("Started: %s (up %s)", info.StartTime.Format(time.RFC3339), info.Uptime.Round(time.Second)))
+	}
+	gl.Log("info", fmt.Sprintf("Memory (RSS): %.1f MiB, threads: %d", float64(info.RSS)/(1<<20), info.Threads))
+	if info.OpenFDs >= 0 {
+		gl.Log("info", fmt.Sprintf("Open file descriptors: %d", info.OpenFDs))
+	}
+}
+
 func checkCommand() *cobra.Command {
+	var minGo string
+
 	var checkCmd = &cobra.Command{
 		Use: "check",
 		Aliases: []string{"health"},
@@ -175,13 +298,22 @@ func checkCommand() *cobra.Command {
 		}, false),
 		Run: func(cmd *cobra.Command, args []string) {
 			sr := selfrestart.New()
+			if minGo != "" {
+				sr.SetMinimumGoVersion(minGo)
+			}
 
 			gl.Log("info", "Checking system requirements...")
 
 			// Check Go installation
-			if sr.IsGolangInstalled() {
-				gl.Log("success", "✅ Go is installed and accessible")
-			} else {
+			inst, err := sr.DetectGo()
+			switch {
+			case err != nil:
+				gl.Log("error", fmt.Sprintf("❌ Could not check Go installation: %v", err))
+			case inst.Status == selfrestart.GoOK:
+				gl.Log("success", fmt.Sprintf("✅ Go %s is installed at %s (minimum %s)", inst.Version, inst.Path, inst.Minimum))
+			case inst.Status == selfrestart.GoTooOld:
+				gl.Log("warn", fmt.Sprintf("⚠️  Go %s at %s is older than the minimum %s", inst.Version, inst.Path, inst.Minimum))
+			default:
 				gl.Log("error", "❌ Go is not installed or not found in PATH")
 			}
 
@@ -215,5 +347,7 @@ func checkCommand() *cobra.Command {
 		},
 	}
 
+	checkCmd.Flags().StringVarP(&minGo, "min-go", "", "", "Minimum Go version required (default: the version selfrestart targets)")
+
 	return checkCmd
 }
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rafa-mori/selfrestart"
+	gl "github.com/rafa-mori/selfrestart/logger"
+	"github.com/spf13/cobra"
+)
+
+// signalNames maps the signals accepted by --signal to their names.
+var signalNames = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+}
+
+// parseSignal accepts a signal name with or without the SIG prefix.
+func parseSignal(name string) (syscall.Signal, error) {
+	sig, ok := signalNames[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return 0, fmt.Errorf("unknown signal %q", name)
+	}
+	return sig, nil
+}
+
+// signalName returns the SIG-prefixed name of sig.
+func signalName(sig syscall.Signal) string {
+	for name, s := range signalNames {
+		if s == sig {
+			return "SIG" + name
+		}
+	}
+	return sig.String()
+}
+
+func stopCommand() *cobra.Command {
+	var signal string
+	var timeout time.Duration
+	var killTimeout time.Duration
+	var noKill bool
+	var target pidTarget
+
+	var stopCmd = &cobra.Command{
+		Use: "stop",
+		Annotations: GetDescriptions([]string{
+			"Stop a process gracefully.",
+			"This command sends a signal to the target process, waits for it to exit and kills it when it does not exit in time.",
+		}, false),
+		Run: func(cmd *cobra.Command, args []string) {
+			sr := selfrestart.New()
+
+			sig, err := parseSignal(signal)
+			if err != nil {
+				gl.Log("error", err.Error())
+				os.Exit(1)
+			}
+			targetPIDs, err := target.resolveAll(context.Background(), sr)
+			if err != nil {
+				gl.Log("error", fmt.Sprintf("Failed to resolve %s: %v", target.describe(), err))
+				os.Exit(1)
+			}
+			if len(targetPIDs) == 0 {
+				gl.Log("error", "No process selected, use --pid, --pidfile, --service, --name or --exe")
+				os.Exit(1)
+			}
+
+			steps := []selfrestart.TerminateStep{{Signal: sig, Timeout: timeout}}
+			if !noKill && sig != syscall.SIGKILL {
+				steps = append(steps, selfrestart.TerminateStep{Signal: syscall.SIGKILL, Timeout: killTimeout})
+			}
+			failed := false
+			for _, pid := range targetPIDs {
+				if !stopProcess(sr, pid, steps) {
+					failed = true
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	stopCmd.Flags().StringVarP(&signal, "signal", "", "TERM", "Signal sent first (TERM, INT, HUP, QUIT, USR1, USR2 or KILL)")
+	stopCmd.Flags().DurationVarP(&timeout, "timeout", "t", 10*time.Second, "Time the process gets to exit after the signal")
+	stopCmd.Flags().DurationVarP(&killTimeout, "kill-timeout", "", 5*time.Second, "Time to wait for the exit after SIGKILL")
+	stopCmd.Flags().BoolVarP(&noKill, "no-kill", "", false, "Do not send SIGKILL when the process does not exit in time")
+	target.addFlags(stopCmd, "PID of process to stop")
+	target.addDiscoveryFlags(stopCmd)
+
+	return stopCmd
+}
+
+// stopProcess terminates pid with steps and reports whether it is gone.
+func stopProcess(sr *selfrestart.SelfRestart, pid int, steps []selfrestart.TerminateStep) bool {
+	gl.Log("info", fmt.Sprintf("Stopping process %d with %s", pid, signalName(steps[0].Signal)))
+	res, err := sr.Terminate(pid, selfrestart.TerminateOptions{Steps: steps})
+	if err != nil && !errors.Is(err, selfrestart.ErrStillRunning) {
+		gl.Log("error", fmt.Sprintf("Failed to stop process %d: %v", pid, err))
+		return false
+	}
+
+	sent := make([]string, len(res.Signals))
+	for i, s := range res.Signals {
+		sent[i] = signalName(s)
+	}
+	switch {
+	case !res.Exited:
+		gl.Log("error", fmt.Sprintf("Process %d is still running after %s (sent: %s)", pid, res.Elapsed.Round(time.Millisecond), strings.Join(sent, ", ")))
+		return false
+	case len(sent) == 0:
+		gl.Log("warn", fmt.Sprintf("Process %d is not running", pid))
+	default:
+		gl.Log("success", fmt.Sprintf("Process %d stopped after %s (sent: %s, detected via %s)", pid, res.Elapsed.Round(time.Millisecond), strings.Join(sent, ", "), res.Method))
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rafa-mori/selfrestart"
+	gl "github.com/rafa-mori/selfrestart/logger"
+	"github.com/spf13/cobra"
+)
+
+// pidTarget holds the flags that select the process a command acts on.
+type pidTarget struct {
+	pid     int
+	pidFile string
+	service string
+	name    string
+	exe     string
+	all     bool
+}
+
+func (t *pidTarget) addFlags(cmd *cobra.Command, pidUsage string) {
+	cmd.Flags().IntVarP(&t.pid, "pid", "p", 0, pidUsage)
+	cmd.Flags().StringVarP(&t.pidFile, "pidfile", "", "", "Read the PID from this pidfile")
+	cmd.Flags().StringVarP(&t.service, "service", "s", "", "Read the PID from the pidfile of this service name")
+}
+
+// addDiscoveryFlags adds the flags that select processes by name or
+// executable, for the commands that go through resolveAll.
+func (t *pidTarget) addDiscoveryFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&t.name, "name", "", "", "Select the processes with this name")
+	cmd.Flags().StringVarP(&t.exe, "exe", "", "", "Select the processes running this executable")
+	cmd.Flags().BoolVarP(&t.all, "all", "", false, "Act on every process matched by --name or --exe without asking")
+}
+
+// resolveAll returns the selected PIDs, or none when no target flag was
+// given. When --name or --exe match several processes, they are listed and
+// the user is asked to confirm unless --all is set.
+func (t *pidTarget) resolveAll(ctx context.Context, sr *selfrestart.SelfRestart) ([]int, error) {
+	var matches []selfrestart.ProcessMatch
+	var err error
+	switch {
+	case t.name != "":
+		matches, err = sr.FindByName(t.name)
+	case t.exe != "":
+		matches, err = sr.FindByExecutable(t.exe)
+	default:
+		pid, err := t.resolve(sr)
+		if err != nil || pid == 0 {
+			return nil, err
+		}
+		return []int{pid}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no process found")
+	}
+
+	pids := make([]int, len(matches))
+	for i, m := range matches {
+		pids[i] = m.PID
+	}
+	if len(matches) == 1 || t.all {
+		return pids, nil
+	}
+	gl.Log("info", fmt.Sprintf("%d processes match %s:", len(matches), t.describe()))
+	for _, m := range matches {
+		gl.Log("info", fmt.Sprintf("  %d  %s", m.PID, strings.Join(m.Args, " ")))
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	ok, err := sr.Confirm(ctx, fmt.Sprintf("Act on all %d processes?", len(matches)), false)
+	if err != nil {
+		return nil, fmt.Errorf("no confirmation to act on %d processes (use --all): %v", len(matches), err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("canceled, %d processes match (use --pid to pick one)", len(matches))
+	}
+	return pids, nil
+}
+
+// resolve returns the selected PID, or 0 when no target flag was given.
+func (t *pidTarget) resolve(sr *selfrestart.SelfRestart) (int, error) {
+	switch {
+	case t.pid > 0:
+		return t.pid, nil
+	case t.pidFile != "":
+		return sr.ReadPIDFile(t.pidFile)
+	case t.service != "":
+		return sr.ReadPIDFile(selfrestart.PIDFilePath(t.service))
+	default:
+		return 0, nil
+	}
+}
+
+// pidFilePath returns the pidfile selected by --pidfile or --service, falling
+// back to the pidfile of defaultService.
+func (t *pidTarget) pidFilePath(defaultService string) string {
+	if t.pidFile != "" {
+		return t.pidFile
+	}
+	if t.service != "" {
+		return selfrestart.PIDFilePath(t.service)
+	}
+	return selfrestart.PIDFilePath(defaultService)
+}
+
+// describe returns a short label of the selected target for messages.
+func (t *pidTarget) describe() string {
+	switch {
+	case t.name != "":
+		return fmt.Sprintf("name %s", t.name)
+	case t.exe != "":
+		return fmt.Sprintf("executable %s", t.exe)
+	case t.pidFile != "":
+		return fmt.Sprintf("pidfile %s", t.pidFile)
+	case t.service != "":
+		return fmt.Sprintf("service %s", t.service)
+	default:
+		return fmt.Sprintf("PID %d", t.pid)
+	}
+}
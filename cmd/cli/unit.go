@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/unit"
+	gl "github.com/rafa-mori/selfrestart/logger"
+	"github.com/spf13/cobra"
+)
+
+// unitFlags collects the service unit settings shared by the service
+// subcommands.
+type unitFlags struct {
+	name        string
+	exec        string
+	format      string
+	root        string
+	description string
+	user        string
+	workDir     string
+	env         []string
+	notify      bool
+	watchdog    time.Duration
+	policy      policyFlags
+}
+
+func (f *unitFlags) addTargetFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.name, "name", "n", "", "Service name")
+	cmd.Flags().StringVarP(&f.format, "format", "f", string(unit.DefaultFormat()), "Service manager: systemd, openrc or launchd")
+	_ = cmd.MarkFlagRequired("name")
+}
+
+func (f *unitFlags) addSpecFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.exec, "exec", "e", "", "Absolute path of the program (default: this selfrestart binary)")
+	cmd.Flags().StringVarP(&f.description, "description", "", "", "Service description (default: the name)")
+	cmd.Flags().StringVarP(&f.user, "user", "u", "", "Run the service as this user")
+	cmd.Flags().StringVarP(&f.workDir, "workdir", "", "", "Working directory of the service")
+	cmd.Flags().StringArrayVarP(&f.env, "env", "", nil, "Environment variable KEY=VALUE (repeatable)")
+	cmd.Flags().BoolVarP(&f.notify, "notify", "", false, "The program links selfrestart: use sd_notify readiness and SIGUSR1 reloads")
+	cmd.Flags().DurationVarP(&f.watchdog, "watchdog", "", 0, "systemd watchdog interval, requires --notify (0 disables)")
+	f.policy.addFlags(cmd)
+}
+
+// spec builds the unit spec from the flags and the arguments after "--".
+func (f *unitFlags) spec(cmd *cobra.Command, args []string) (unit.Spec, error) {
+	restartPolicy, err := f.policy.resolve(cmd)
+	if err != nil {
+		return unit.Spec{}, err
+	}
+	exec := f.exec
+	if exec == "" {
+		if exec, err = os.Executable(); err != nil {
+			return unit.Spec{}, fmt.Errorf("could not resolve the selfrestart binary: %v", err)
+		}
+	}
+	if exec, err = filepath.Abs(exec); err != nil {
+		return unit.Spec{}, err
+	}
+	env := make(map[string]string, len(f.env))
+	for _, kv := range f.env {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return unit.Spec{}, fmt.Errorf("invalid --env %q, expected KEY=VALUE", kv)
+		}
+		env[key] = value
+	}
+	return unit.Spec{
+		Name:        f.name,
+		Description: f.description,
+		Exec:        exec,
+		Args:        args,
+		User:        f.user,
+		WorkingDir:  f.workDir,
+		Env:         env,
+		Policy:      restartPolicy,
+		Notify:      f.notify,
+		Watchdog:    f.watchdog,
+	}, nil
+}
+
+func serviceCommand() *cobra.Command {
+	var serviceCmd = &cobra.Command{
+		Use: "service",
+		Annotations: GetDescriptions([]string{
+			"Install, remove or print service units.",
+			"This command renders systemd units, OpenRC init scripts and launchd plists that keep a program running with a restart policy.",
+		}, false),
+	}
+	serviceCmd.Annotations["service"] = "true"
+
+	serviceCmd.AddCommand(
+		serviceInstallCommand(),
+		serviceUninstallCommand(),
+		servicePrintCommand(),
+	)
+	return serviceCmd
+}
+
+func serviceInstallCommand() *cobra.Command {
+	var opts unitFlags
+
+	var installCmd = &cobra.Command{
+		Use: "install --name <name> [flags] [-- args...]",
+		Annotations: GetDescriptions([]string{
+			"Install a service unit.",
+			"This command writes the unit of the service below --root, ready to be enabled with the service manager.",
+		}, false),
+		Run: func(cmd *cobra.Command, args []string) {
+			spec, err := opts.spec(cmd, args)
+			if err != nil {
+				gl.Log("error", err.Error())
+				os.Exit(1)
+			}
+			path, err := unit.Install(unit.Format(opts.format), opts.root, spec)
+			if err != nil {
+				gl.Log("error", fmt.Sprintf("Failed to install service %s: %v", spec.Name, err))
+				os.Exit(1)
+			}
+			gl.Log("success", fmt.Sprintf("Installed %s", path))
+			switch unit.Format(opts.format) {
+			case unit.Systemd:
+				gl.Log("info", fmt.Sprintf("Enable it with: systemctl daemon-reload && systemctl enable --now %s", spec.Name))
+			case unit.OpenRC:
+				gl.Log("info", fmt.Sprintf("Enable it with: rc-update add %s default && rc-service %s start", spec.Name, spec.Name))
+			case unit.Launchd:
+				gl.Log("info", fmt.Sprintf("Load it with: launchctl load -w %s", path))
+			}
+		},
+	}
+
+	opts.addTargetFlags(installCmd)
+	opts.addSpecFlags(installCmd)
+	installCmd.Flags().StringVarP(&opts.root, "root", "", "/", "Root directory the unit is written below")
+
+	return installCmd
+}
+
+func serviceUninstallCommand() *cobra.Command {
+	var opts unitFlags
+
+	var uninstallCmd = &cobra.Command{
+		Use: "uninstall --name <name>",
+		Annotations: GetDescriptions([]string{
+			"Remove a service unit.",
+			"This command removes the unit of the service from below --root. Stop and disable the service first.",
+		}, false),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := unit.Uninstall(unit.Format(opts.format), opts.root, opts.name)
+			if err != nil {
+				gl.Log("error", fmt.Sprintf("Failed to uninstall service %s: %v", opts.name, err))
+				os.Exit(1)
+			}
+			gl.Log("success", fmt.Sprintf("Removed %s", path))
+		},
+	}
+
+	opts.addTargetFlags(uninstallCmd)
+	uninstallCmd.Flags().StringVarP(&opts.root, "root", "", "/", "Root directory the unit was written below")
+
+	return uninstallCmd
+}
+
+func servicePrintCommand() *cobra.Command {
+	var opts unitFlags
+
+	var printCmd = &cobra.Command{
+		Use: "print --name <name> [flags] [-- args...]",
+		Annotations: GetDescriptions([]string{
+			"Print a service unit.",
+			"This command renders the unit of the service to standard output without installing it.",
+		}, false),
+		Run: func(cmd *cobra.Command, args []string) {
+			spec, err := opts.spec(cmd, args)
+			if err != nil {
+				gl.Log("error", err.Error())
+				os.Exit(1)
+			}
+			data, err := unit.Render(unit.Format(opts.format), spec)
+			if err != nil {
+				gl.Log("error", err.Error())
+				os.Exit(1)
+			}
+			fmt.Print(string(data))
+		},
+	}
+
+	opts.addTargetFlags(printCmd)
+	opts.addSpecFlags(printCmd)
+
+	return printCmd
+}
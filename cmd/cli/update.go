@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rafa-mori/selfrestart"
+	gl "github.com/rafa-mori/selfrestart/logger"
+	"github.com/rafa-mori/selfrestart/prompt"
+	"github.com/rafa-mori/selfrestart/updater"
+	vs "github.com/rafa-mori/selfrestart/version"
+	"github.com/spf13/cobra"
+)
+
+func updateCommand() *cobra.Command {
+	var check bool
+	var toVersion string
+	var channel string
+	var yes bool
+	var noRestart bool
+	var restartTarget pidTarget
+
+	var updateCmd = &cobra.Command{
+		Use: "update",
+		Annotations: GetDescriptions([]string{
+			"Update SelfRestart to a newer release.",
+			"This command downloads, verifies and installs a SelfRestart release, then optionally restarts the target process.",
+		}, false),
+		Run: func(cmd *cobra.Command, args []string) {
+			if channel != "stable" && channel != "pre" {
+				gl.Log("error", fmt.Sprintf("Invalid channel %q, use stable or pre", channel))
+				os.Exit(1)
+			}
+
+			u := updater.New(updater.Config{})
+			current := strings.TrimSpace(vs.GetVersion())
+
+			target := toVersion
+			if target == "" {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				latest, err := u.LatestVersion(ctx, channel == "pre")
+				cancel()
+				if err != nil {
+					gl.Log("error", fmt.Sprintf("Failed to resolve latest version: %v", err))
+					os.Exit(1)
+				}
+				target = latest
+			}
+
+			gl.Log("info", fmt.Sprintf("Current version: %s", current))
+			gl.Log("info", fmt.Sprintf("Target version: %s (%s channel)", target, channel))
+
+			newer := true
+			if comp, err := vs.Compare(target, current); err == nil {
+				newer = comp > 0
+			}
+			if check {
+				if newer {
+					gl.Log("warn", "An update is available")
+				} else {
+					gl.Log("success", "You are using the latest version")
+				}
+				return
+			}
+			if !newer && toVersion == "" {
+				gl.Log("success", "Already up to date")
+				return
+			}
+
+			prompter := prompt.Default()
+			if yes {
+				prompter = prompt.AssumeYes
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			ok, err := prompter.Confirm(ctx, fmt.Sprintf("Update from %s to %s?", current, target), true)
+			cancel()
+			if err != nil {
+				gl.Log("warn", fmt.Sprintf("Update canceled: %v (use --yes or %s=1 to skip the question)", err, prompt.EnvAssumeYes))
+				return
+			}
+			if !ok {
+				gl.Log("warn", "Update canceled")
+				return
+			}
+
+			binPath, err := os.Executable()
+			if err != nil {
+				gl.Log("error", fmt.Sprintf("Failed to locate current binary: %v", err))
+				os.Exit(1)
+			}
+			if resolved, err := filepath.EvalSymlinks(binPath); err == nil {
+				binPath = resolved
+			}
+
+			if err := u.Apply(context.Background(), u.Release(target), binPath); err != nil {
+				gl.Log("error", fmt.Sprintf("Update failed: %v", err))
+				os.Exit(1)
+			}
+			gl.Log("success", fmt.Sprintf("Updated %s to %s", binPath, target))
+
+			if noRestart {
+				return
+			}
+			targetPID, err := restartTarget.resolve(selfrestart.New())
+			if err != nil {
+				gl.Log("error", fmt.Sprintf("Failed to resolve %s: %v", restartTarget.describe(), err))
+				os.Exit(1)
+			}
+			if targetPID <= 0 {
+				return
+			}
+			if err := signalRestart(targetPID); err != nil {
+				gl.Log("error", err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	updateCmd.Flags().BoolVarP(&check, "check", "c", false, "Only report whether an update is available")
+	updateCmd.Flags().StringVarP(&toVersion, "to", "t", "", "Install this version instead of the latest one")
+	updateCmd.Flags().StringVarP(&channel, "channel", "", "stable", "Release channel: stable or pre")
+	updateCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+	updateCmd.Flags().BoolVarP(&noRestart, "no-restart", "", false, "Do not restart the target process after updating")
+	restartTarget.addFlags(updateCmd, "PID of the process to restart after updating")
+
+	return updateCmd
+}
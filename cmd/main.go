@@ -1,6 +1,9 @@
 package main
 
 import (
+	"os"
+
+	"github.com/rafa-mori/selfrestart"
 	gl "github.com/rafa-mori/selfrestart/logger"
 )
 
@@ -10,6 +13,10 @@ import (
 
 // main initializes the logger and creates a new GoBE instance.
 func main() {
+	// A restart validation only needs to know that the binary starts
+	if selfrestart.IsProbe() {
+		os.Exit(0)
+	}
 	if err := RegX().Command().Execute(); err != nil {
 		gl.Log("fatal", err.Error())
 	}
@@ -33,7 +33,17 @@ func (m *SelfRestart) Examples() []string {
 		"selfrestart restart --wait",
 		"selfrestart restart --pid 12345",
 		"selfrestart status --pid 12345",
+		"selfrestart status --service selfrestart",
+		"selfrestart restart --pidfile /run/selfrestart/my-service.pid",
+		"selfrestart stop --service my-service --timeout 30s",
+		"selfrestart status --name my-service",
+		"selfrestart stop --exe /usr/local/bin/my-service --all",
 		"selfrestart check",
+		"selfrestart run --max-restarts 3 -- ./my-service --port 8080",
+		"selfrestart update --check",
+		"selfrestart update --yes --pid 12345",
+		"selfrestart service install --name my-service --exec /usr/local/bin/my-service --notify -- --port 8080",
+		"selfrestart service print --name my-service --format launchd --exec /usr/local/bin/my-service",
 	}
 }
 func (m *SelfRestart) Active() bool {
@@ -1,11 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/rafa-mori/selfrestart"
@@ -34,9 +33,8 @@ func main() {
 	pid := sr.GetCurrentPID()
 	fmt.Printf("🆔 PID atual: %d\n", pid)
 
-	// Configurar um canal para capturar sinais de sistema
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
+	// Instala os handlers de SIGUSR1 (reinício), SIGHUP (recarga) e SIGINT/SIGTERM (saída)
+	events := sr.WatchSignals(context.Background(), selfrestart.SignalOptions{})
 
 	fmt.Println("\n📋 Comandos disponíveis:")
 	fmt.Println("  - Ctrl+C: Sair normalmente")
@@ -50,17 +48,20 @@ func main() {
 
 	for {
 		select {
-		case sig := <-sigChan:
-			switch sig {
-			case syscall.SIGINT, syscall.SIGTERM:
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			switch ev.Type {
+			case selfrestart.EventTerminated:
 				fmt.Println("\n👋 Recebido sinal de saída. Finalizando...")
 				return
-			case syscall.SIGUSR1:
+			case selfrestart.EventRestarting:
 				fmt.Println("\n🔄 Recebido sinal de reinício. Reiniciando aplicação...")
-				if err := sr.Restart(); err != nil {
-					log.Fatalf("❌ Erro ao reiniciar: %v", err)
-				}
-				// Após chamar Restart(), o processo atual deve terminar
+			case selfrestart.EventRestartFailed:
+				log.Fatalf("❌ Erro ao reiniciar: %v", ev.Err)
+			case selfrestart.EventRestarted:
+				// Após o reinício, o processo atual deve terminar
 				fmt.Println("👋 Processo atual finalizando para permitir reinício...")
 				os.Exit(0)
 			}
@@ -0,0 +1,32 @@
+package selfrestart
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rafa-mori/selfrestart/internal/restart"
+)
+
+// HelperCommand is the hidden first argument used when Restart starts the
+// binary as its own restart helper.
+const HelperCommand = restart.HelperCommand
+
+// The restart helper is a copy of the application binary started with
+// HelperCommand. It must take over before any application code runs, so it
+// is dispatched from init, which exits the process once the helper is done;
+// without that argument init does nothing. This is the only side effect of
+// importing the package.
+func init() {
+	if len(os.Args) > 1 && os.Args[1] == HelperCommand {
+		os.Exit(runHelper(os.Args[2:]))
+	}
+}
+
+// runHelper runs helper mode and returns the exit code of the helper
+func runHelper(args []string) int {
+	if err := restart.NewRestarter().RunHelper(args); err != nil {
+		fmt.Fprintf(os.Stderr, "selfrestart helper: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+//go:build linux
+
+package selfrestart
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/restart"
+)
+
+// TestHelperDispatch starts this test binary as the restart helper, which
+// init must pick up before the tests run, and checks what reaches the new
+// process.
+func TestHelperDispatch(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Skipf("could not run true: %v", err)
+	}
+
+	args := []string{"with space", "", "KEY=a=b"}
+	script := `printf '%s\n' "$@" > "$OUT"; printf '%s\n' "$SELFRESTART_TEST" >> "$OUT"`
+	spec := restart.Spec{
+		PID:     exited.Process.Pid,
+		BinPath: "/bin/sh",
+		Args:    append([]string{"-c", script, "sh"}, args...),
+		Env:     []string{"OUT=" + out, "SELFRESTART_TEST=x=y=z", "PATH=" + os.Getenv("PATH")},
+		Dir:     dir,
+		LogPath: filepath.Join(dir, "helper.log"),
+	}
+	if err := restart.NewRestarter().StartHelper(spec); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		data, err := os.ReadFile(out)
+		if err == nil && strings.Count(string(data), "\n") == len(args)+1 {
+			got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if want := append(args, "x=y=z"); !reflect.DeepEqual(got, want) {
+				t.Fatalf("new process got %q, want %q", got, want)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			log, _ := os.ReadFile(spec.LogPath)
+			t.Fatalf("new process did not run; helper log:\n%s", log)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
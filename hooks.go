@@ -0,0 +1,119 @@
+package selfrestart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultHookTimeout is the deadline shared by the hooks of one phase of a
+// restart or kill when no other timeout is configured.
+const DefaultHookTimeout = 10 * time.Second
+
+// Hook is a lifecycle callback. It should return promptly once ctx is done.
+type Hook func(ctx context.Context) error
+
+// ErrRestartCanceled is returned by Restart when a before-restart hook fails
+// or the hook deadline expires.
+var ErrRestartCanceled = errors.New("reinício cancelado")
+
+// Hooks is a registry of lifecycle hooks. Every SelfRestart has its own
+// unless one is shared through WithHooks.
+type Hooks struct {
+	mu            sync.Mutex
+	beforeRestart []Hook
+	shutdown      []Hook
+}
+
+// NewHooks returns an empty hook registry.
+func NewHooks() *Hooks {
+	return &Hooks{}
+}
+
+// OnBeforeRestart registers a hook that runs before a restart starts. An
+// error from any of them cancels the restart.
+func (h *Hooks) OnBeforeRestart(hook Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.beforeRestart = append(h.beforeRestart, hook)
+}
+
+// OnShutdown registers a hook that runs right before the current process goes
+// away, either after a restart has been handed off or before it is killed.
+func (h *Hooks) OnShutdown(hook Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.shutdown = append(h.shutdown, hook)
+}
+
+// OnBeforeRestart registers a before-restart hook; see Hooks.OnBeforeRestart.
+func (sr *SelfRestart) OnBeforeRestart(hook Hook) {
+	sr.hooks.OnBeforeRestart(hook)
+}
+
+// OnShutdown registers a shutdown hook; see Hooks.OnShutdown.
+func (sr *SelfRestart) OnShutdown(hook Hook) {
+	sr.hooks.OnShutdown(hook)
+}
+
+func (h *Hooks) snapshot() (before, shutdown []Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Hook(nil), h.beforeRestart...), append([]Hook(nil), h.shutdown...)
+}
+
+// runBeforeRestart runs the before-restart hooks in order and stops at the
+// first failure. Hooks may be shared between instances, so the logger of the
+// calling instance is passed in.
+func (h *Hooks) runBeforeRestart(ctx context.Context, logger Logger) error {
+	before, _ := h.snapshot()
+	for i, hook := range before {
+		if err := runHook(ctx, logger, hook); err != nil {
+			return fmt.Errorf("%w: hook before-restart #%d: %v", ErrRestartCanceled, i+1, err)
+		}
+	}
+	return nil
+}
+
+// runShutdown runs every shutdown hook in order, even when one fails, and
+// returns the joined errors.
+func (h *Hooks) runShutdown(ctx context.Context, logger Logger) error {
+	_, shutdown := h.snapshot()
+	var errs []error
+	for i, hook := range shutdown {
+		if err := runHook(ctx, logger, hook); err != nil {
+			errs = append(errs, fmt.Errorf("hook shutdown #%d: %v", i+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runHook runs hook and gives up when ctx is done, so a hook that ignores its
+// context cannot hold the restart past the shared deadline. The overrun is
+// reported to logger.
+func runHook(ctx context.Context, logger Logger, hook Hook) error {
+	if hook == nil {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- hook(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		logger.Log("warn", "Hook não terminou dentro do prazo")
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+package selfrestart
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLogger keeps the messages it is given.
+type recordingLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *recordingLogger) Log(level, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, level+": "+msg)
+}
+
+func TestSharedHooksLogThroughEachInstance(t *testing.T) {
+	hooks := NewHooks()
+	release := make(chan struct{})
+	defer close(release)
+	hooks.OnShutdown(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	var first, second recordingLogger
+	for _, logger := range []*recordingLogger{&first, &second} {
+		sr := New(WithHooks(hooks), WithLogger(logger))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := sr.hooks.runShutdown(ctx, sr.logger)
+		cancel()
+		if err == nil {
+			t.Fatal("runShutdown of an overrunning hook succeeded")
+		}
+	}
+	for i, logger := range []*recordingLogger{&first, &second} {
+		if len(logger.msgs) != 1 || logger.msgs[0] != "warn: Hook não terminou dentro do prazo" {
+			t.Errorf("logger %d got %q", i+1, logger.msgs)
+		}
+	}
+}
+
+func TestBeforeRestartHookFailureCancels(t *testing.T) {
+	hooks := NewHooks()
+	ran := 0
+	hooks.OnBeforeRestart(func(ctx context.Context) error { ran++; return errors.New("busy") })
+	hooks.OnBeforeRestart(func(ctx context.Context) error { ran++; return nil })
+
+	err := hooks.runBeforeRestart(context.Background(), &recordingLogger{})
+	if !errors.Is(err, ErrRestartCanceled) {
+		t.Fatalf("runBeforeRestart = %v, want ErrRestartCanceled", err)
+	}
+	if ran != 1 {
+		t.Fatalf("%d hooks ran, want 1", ran)
+	}
+}
@@ -0,0 +1,132 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/platform"
+	"github.com/rafa-mori/selfrestart/version"
+)
+
+// GoStatus classifies the Go installation found on the system.
+type GoStatus string
+
+const (
+	// GoOK means a go command at least as new as the minimum was found.
+	GoOK GoStatus = "installed"
+	// GoTooOld means the go command found is older than the minimum.
+	GoTooOld GoStatus = "too_old"
+	// GoMissing means no usable go command was found.
+	GoMissing GoStatus = "missing"
+)
+
+// GoInstallation describes the go command found in PATH.
+type GoInstallation struct {
+	// Path of the go command; empty when missing.
+	Path string
+	// Version as reported by the toolchain, such as "go1.24.4".
+	Version string
+	// Minimum is the version the installation was checked against.
+	Minimum string
+	Status  GoStatus
+}
+
+// DetectGo looks up go in PATH and reads its version from `go env
+// GOVERSION`, falling back to $GOROOT/VERSION, then checks it against
+// i.MinimumVersion (DefaultGoVersion when empty). The host entry of
+// platform.FullPlatformMap is updated with the result.
+func (i *Installer) DetectGo(ctx context.Context) (*GoInstallation, error) {
+	minimum := i.MinimumVersion
+	if minimum == "" {
+		minimum = DefaultGoVersion
+	}
+	inst := &GoInstallation{Minimum: minimum, Status: GoMissing}
+	host := platform.GetHostPlatform()
+
+	goPath, err := exec.LookPath(goBinary(host.OS))
+	if err != nil {
+		platform.SetInstalled(host.OS, false, "")
+		return inst, nil
+	}
+	inst.Path = goPath
+
+	goVersion, err := goEnvVersion(ctx, goPath)
+	if err != nil {
+		if goVersion, err = gorootVersion(goPath); err != nil {
+			platform.SetInstalled(host.OS, false, "")
+			return inst, fmt.Errorf("could not determine the version of %s: %v", goPath, err)
+		}
+	}
+	inst.Version = goVersion
+
+	ok, err := AtLeast(goVersion, minimum)
+	if err != nil {
+		return inst, err
+	}
+	inst.Status = GoTooOld
+	if ok {
+		inst.Status = GoOK
+	}
+	platform.SetInstalled(host.OS, true, goVersion)
+	return inst, nil
+}
+
+// AtLeast reports whether the Go version goVersion, in any of the forms
+// "go1.24.4", "1.24" or "devel go1.25-abcdef", is not older than minimum.
+// Development builds always qualify.
+func AtLeast(goVersion, minimum string) (bool, error) {
+	fields := strings.Fields(goVersion)
+	if len(fields) == 0 {
+		return false, fmt.Errorf("empty go version")
+	}
+	if fields[0] == "devel" {
+		return true, nil
+	}
+	c, err := version.Compare(fields[0], minimum)
+	if err != nil {
+		return false, err
+	}
+	return c >= 0, nil
+}
+
+// goEnvVersion runs `go env GOVERSION`.
+func goEnvVersion(ctx context.Context, goPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, goPath, "env", "GOVERSION").Output()
+	if err != nil {
+		return "", err
+	}
+	goVersion := strings.TrimSpace(string(out))
+	if goVersion == "" {
+		return "", fmt.Errorf("go env GOVERSION printed nothing")
+	}
+	return goVersion, nil
+}
+
+// gorootVersion reads the first line of $GOROOT/VERSION, deriving GOROOT
+// from the location of the go command when the variable is unset.
+func gorootVersion(goPath string) (string, error) {
+	goroot := os.Getenv("GOROOT")
+	if goroot == "" {
+		resolved, err := filepath.EvalSymlinks(goPath)
+		if err != nil {
+			return "", err
+		}
+		goroot = filepath.Dir(filepath.Dir(resolved))
+	}
+	data, err := os.ReadFile(filepath.Join(goroot, "VERSION"))
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	if line = strings.TrimSpace(line); line == "" {
+		return "", fmt.Errorf("empty VERSION file in %s", goroot)
+	}
+	return line, nil
+}
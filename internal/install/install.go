@@ -1,17 +1,22 @@
 package install
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
 
-type Installer struct{}
+type Installer struct {
+	Toolchains *ToolchainManager
+	// MinimumVersion is the oldest Go version DetectGo accepts; empty means
+	// DefaultGoVersion.
+	MinimumVersion string
+}
 
 func NewInstaller() *Installer {
-	return &Installer{}
+	return &Installer{Toolchains: NewToolchainManager()}
 }
 
 func (i *Installer) IsInPath(target string) (bool, error) {
@@ -49,18 +54,16 @@ func (i *Installer) IsInPath(target string) (bool, error) {
 	return false, nil
 }
 
-func (i *Installer) InstallGoUnix() (bool, error) {
-	installed, err := i.IsInPath("go")
+// InstallGo installs the requested Go version into the user prefix of the
+// toolchain manager, without sudo, and puts it first in PATH for the current
+// process. See ToolchainManager.Resolve for the accepted versions.
+func (i *Installer) InstallGo(ctx context.Context, requested string) (*Toolchain, error) {
+	t, err := i.Toolchains.Install(ctx, requested)
 	if err != nil {
-		return false, err
-	}
-	if installed {
-		return true, nil
+		return nil, err
 	}
-	cmd := exec.Command("sh", "-c", "curl -sSL https://golang.org/dl/go1.20.5.linux-amd64.tar.gz | sudo tar -C /usr/local -xzf -")
-	if err := cmd.Run(); err != nil {
-		return false, err
+	if err := t.Activate(); err != nil {
+		return nil, fmt.Errorf("could not update PATH: %v", err)
 	}
-	installed, err = i.IsInPath("go")
-	return installed, err
+	return t, nil
 }
@@ -0,0 +1,407 @@
+package install
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/platform"
+	"github.com/rafa-mori/selfrestart/version"
+)
+
+const (
+	// DefaultMirrorURL serves the official release listing and archives.
+	DefaultMirrorURL = "https://go.dev/dl/"
+	// DefaultGoVersion is installed when no version is requested and is the
+	// default minimum of DetectGo. It matches the go directive of go.mod,
+	// the oldest release that builds this module.
+	DefaultGoVersion = "1.24.4"
+	// EnvGoMirror overrides DefaultMirrorURL.
+	EnvGoMirror = "SELFRESTART_GO_MIRROR"
+)
+
+var (
+	// ErrVersionNotFound is returned when no release matches the request.
+	ErrVersionNotFound = errors.New("go version not found")
+	// ErrChecksumMismatch is returned when a downloaded archive does not
+	// match the listing.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// GoRelease is an entry of the official release listing.
+type GoRelease struct {
+	Version string   `json:"version"`
+	Stable  bool     `json:"stable"`
+	Files   []GoFile `json:"files"`
+}
+
+// GoFile is a downloadable file of a release.
+type GoFile struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Version  string `json:"version"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"`
+}
+
+// Toolchain is an installed Go toolchain.
+type Toolchain struct {
+	Version string
+	GOROOT  string
+}
+
+// Bin returns the directory holding the go command.
+func (t *Toolchain) Bin() string {
+	return filepath.Join(t.GOROOT, "bin")
+}
+
+// Activate puts the toolchain first in PATH for the current process and
+// the commands it starts.
+func (t *Toolchain) Activate() error {
+	path := os.Getenv("PATH")
+	for _, dir := range filepath.SplitList(path) {
+		if dir == t.Bin() {
+			return nil
+		}
+	}
+	if path == "" {
+		return os.Setenv("PATH", t.Bin())
+	}
+	return os.Setenv("PATH", t.Bin()+string(os.PathListSeparator)+path)
+}
+
+// ToolchainManager resolves, downloads and installs Go toolchains into a
+// user-writable prefix.
+type ToolchainManager struct {
+	// MirrorURL serves "?mode=json&include=all" and the archives by file
+	// name, like DefaultMirrorURL.
+	MirrorURL string
+	// Prefix holds one directory per installed version.
+	Prefix   string
+	Platform platform.PlatformInfo
+	Client   *http.Client
+}
+
+// NewToolchainManager returns a manager for the host platform that installs
+// below DefaultPrefix from the official mirror, or the one in EnvGoMirror.
+func NewToolchainManager() *ToolchainManager {
+	mirror := os.Getenv(EnvGoMirror)
+	if mirror == "" {
+		mirror = DefaultMirrorURL
+	}
+	return &ToolchainManager{
+		MirrorURL: mirror,
+		Prefix:    DefaultPrefix(),
+		Platform:  platform.GetHostPlatform(),
+		Client:    &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// DefaultPrefix returns $XDG_DATA_HOME/selfrestart/go, falling back to
+// ~/.local/share/selfrestart/go.
+func DefaultPrefix() string {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "selfrestart", "go")
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "selfrestart", "go")
+}
+
+// Releases returns the release listing of the mirror.
+func (m *ToolchainManager) Releases(ctx context.Context) ([]GoRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url("?mode=json&include=all"), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch go releases: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch go releases: %s", resp.Status)
+	}
+	var releases []GoRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("could not parse go releases: %v", err)
+	}
+	return releases, nil
+}
+
+// Resolve picks the release matching requested and its archive for the
+// platform. An empty request or "latest" selects the newest stable release,
+// "1.24" the newest stable 1.24.x, and a full version such as "1.24.4" or
+// "go1.25rc1" that exact release.
+func (m *ToolchainManager) Resolve(ctx context.Context, requested string) (*GoRelease, *GoFile, error) {
+	releases, err := m.Releases(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	requested = strings.TrimPrefix(strings.TrimSpace(requested), "go")
+	if requested == "latest" {
+		requested = ""
+	}
+
+	// "1.24" names a release series, anything longer an exact release
+	series := requested == "" || strings.Count(requested, ".") == 1 && strings.Trim(requested, "0123456789.") == ""
+	var candidates []GoRelease
+	for _, rel := range releases {
+		v := strings.TrimPrefix(rel.Version, "go")
+		switch {
+		case !series && v == requested:
+			candidates = append(candidates, rel)
+		case series && rel.Stable && (requested == "" || v == requested || strings.HasPrefix(v, requested+".")):
+			candidates = append(candidates, rel)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		c, err := version.Compare(candidates[i].Version, candidates[j].Version)
+		return err == nil && c > 0
+	})
+
+	arch := goArch(m.Platform.Arch)
+	for i := range candidates {
+		for j := range candidates[i].Files {
+			f := &candidates[i].Files[j]
+			if f.Kind == "archive" && f.OS == m.Platform.OS && f.Arch == arch {
+				return &candidates[i], f, nil
+			}
+		}
+	}
+	if requested == "" {
+		requested = "latest"
+	}
+	return nil, nil, fmt.Errorf("%w: %s for %s/%s", ErrVersionNotFound, requested, m.Platform.OS, m.Platform.Arch)
+}
+
+// Installed returns the toolchain of the exact release goVersion, such as
+// "go1.24.4", when it is already installed below the prefix.
+func (m *ToolchainManager) Installed(goVersion string) (*Toolchain, bool) {
+	t := &Toolchain{Version: goVersion, GOROOT: filepath.Join(m.Prefix, goVersion)}
+	if _, err := os.Stat(filepath.Join(t.Bin(), goBinary(m.Platform.OS))); err != nil {
+		return nil, false
+	}
+	return t, true
+}
+
+// Install resolves requested, downloads its archive, checks it against the
+// SHA-256 of the listing and unpacks it below the prefix. A release that is
+// already installed is reused.
+func (m *ToolchainManager) Install(ctx context.Context, requested string) (*Toolchain, error) {
+	rel, file, err := m.Resolve(ctx, requested)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := m.Installed(rel.Version); ok {
+		return t, nil
+	}
+	if err := os.MkdirAll(m.Prefix, 0755); err != nil {
+		return nil, fmt.Errorf("could not create %s: %v", m.Prefix, err)
+	}
+
+	archive, err := os.CreateTemp(m.Prefix, "."+file.Filename+".*")
+	if err != nil {
+		return nil, fmt.Errorf("could not create download file: %v", err)
+	}
+	defer func() {
+		_ = archive.Close()
+		_ = os.Remove(archive.Name())
+	}()
+	if err := m.download(ctx, file, archive); err != nil {
+		return nil, err
+	}
+
+	staging, err := os.MkdirTemp(m.Prefix, "."+rel.Version+".*")
+	if err != nil {
+		return nil, fmt.Errorf("could not create staging directory: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+	if strings.HasSuffix(file.Filename, ".zip") {
+		var info os.FileInfo
+		if info, err = archive.Stat(); err == nil {
+			err = extractZip(archive, info.Size(), staging)
+		}
+	} else {
+		err = extractTarGz(archive, staging)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not unpack %s: %v", file.Filename, err)
+	}
+
+	// The archives hold a single top-level "go" directory
+	goroot := filepath.Join(m.Prefix, rel.Version)
+	if err := os.Rename(filepath.Join(staging, "go"), goroot); err != nil {
+		return nil, fmt.Errorf("could not install %s: %v", rel.Version, err)
+	}
+	t, ok := m.Installed(rel.Version)
+	if !ok {
+		return nil, fmt.Errorf("could not install %s: go command missing from %s", rel.Version, file.Filename)
+	}
+	return t, nil
+}
+
+// download writes file to dst and verifies its checksum.
+func (m *ToolchainManager) download(ctx context.Context, file *GoFile, dst *os.File) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url(file.Filename), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not download %s: %v", file.Filename, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not download %s: %s", file.Filename, resp.Status)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dst, hash), resp.Body); err != nil {
+		return fmt.Errorf("could not download %s: %v", file.Filename, err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(got, file.SHA256) {
+		return fmt.Errorf("%w: %s has sha256 %s, listing says %s", ErrChecksumMismatch, file.Filename, got, file.SHA256)
+	}
+	_, err = dst.Seek(0, io.SeekStart)
+	return err
+}
+
+// url resolves ref against the mirror URL.
+func (m *ToolchainManager) url(ref string) string {
+	base := m.MirrorURL
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base + ref
+}
+
+// goArch maps GOARCH to the architecture names of the release listing.
+func goArch(arch string) string {
+	if arch == "arm" {
+		return "armv6l"
+	}
+	return arch
+}
+
+func goBinary(goos string) string {
+	if goos == "windows" {
+		return "go.exe"
+	}
+	return "go"
+}
+
+// safeJoin joins name to dir, refusing entries that escape it.
+func safeJoin(dir, name string) (string, error) {
+	path := filepath.Join(dir, name)
+	if path != dir && !strings.HasPrefix(path, dir+string(os.PathSeparator)) {
+		return "", fmt.Errorf("archive entry escapes the destination: %s", name)
+	}
+	return path, nil
+}
+
+func extractTarGz(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = gz.Close() }()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path, err := safeJoin(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(path, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if filepath.IsAbs(hdr.Linkname) {
+				return fmt.Errorf("absolute symlink in archive: %s", hdr.Name)
+			}
+			if _, err := safeJoin(dir, filepath.Join(filepath.Dir(hdr.Name), hdr.Linkname)); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, path); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractZip(r io.ReaderAt, size int64, dir string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		path, err := safeJoin(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(path, rc, f.Mode().Perm()|0600)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
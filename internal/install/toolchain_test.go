@@ -0,0 +1,378 @@
+package install
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rafa-mori/selfrestart/internal/platform"
+)
+
+// entry is a file of a test archive. A non-empty link makes it a symlink,
+// a name ending in "/" a directory.
+type entry struct {
+	name    string
+	content string
+	link    string
+}
+
+func tarGz(t *testing.T, entries ...entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o755, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		switch {
+		case e.link != "":
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.link, 0
+		case strings.HasSuffix(e.name, "/"):
+			hdr.Typeflag, hdr.Size = tar.TypeDir, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, entries ...entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func goArchive(t *testing.T) []byte {
+	return tarGz(t,
+		entry{name: "go/"},
+		entry{name: "go/VERSION", content: "go1.24.4\n"},
+		entry{name: "go/bin/go", content: "#!/bin/sh\n"},
+		entry{name: "go/bin/gofmt", content: "#!/bin/sh\n"},
+		entry{name: "go/misc/go", link: "../bin/go"},
+	)
+}
+
+// mirror is a fake download mirror serving a release listing and archives.
+type mirror struct {
+	*httptest.Server
+	releases  []GoRelease
+	archives  map[string][]byte
+	downloads atomic.Int32
+}
+
+func newMirror(t *testing.T) *mirror {
+	t.Helper()
+	m := &mirror{archives: map[string][]byte{}}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dl/" {
+			if r.URL.Query().Get("mode") != "json" || r.URL.Query().Get("include") != "all" {
+				http.Error(w, "bad listing query", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(m.releases)
+			return
+		}
+		data, ok := m.archives[strings.TrimPrefix(r.URL.Path, "/dl/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		m.downloads.Add(1)
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// add lists goVersion with an archive for each platform, serving data for
+// every one of them.
+func (m *mirror) add(goVersion string, stable bool, data []byte, platforms ...string) {
+	sum := sha256.Sum256(data)
+	rel := GoRelease{Version: goVersion, Stable: stable}
+	rel.Files = append(rel.Files, GoFile{Filename: goVersion + ".src.tar.gz", Version: goVersion, Kind: "source"})
+	for _, p := range platforms {
+		goos, arch, _ := strings.Cut(p, "/")
+		ext := ".tar.gz"
+		if goos == "windows" {
+			ext = ".zip"
+		}
+		name := goVersion + "." + goos + "-" + arch + ext
+		m.archives[name] = data
+		rel.Files = append(rel.Files, GoFile{
+			Filename: name, OS: goos, Arch: arch, Version: goVersion,
+			SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data)), Kind: "archive",
+		})
+	}
+	m.releases = append(m.releases, rel)
+}
+
+func (m *mirror) manager(t *testing.T, goos, arch string) *ToolchainManager {
+	return &ToolchainManager{
+		MirrorURL: m.URL + "/dl",
+		Prefix:    filepath.Join(t.TempDir(), "go"),
+		Platform:  platform.PlatformInfo{OS: goos, Arch: arch},
+		Client:    m.Client(),
+	}
+}
+
+// assertClean fails when the prefix holds anything but the given entries.
+func assertClean(t *testing.T, prefix string, want ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(prefix)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("prefix holds %v, want %v", got, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	m := newMirror(t)
+	data := []byte("archive")
+	m.add("go1.25rc1", false, data, "linux/amd64", "darwin/arm64")
+	m.add("go1.24.4", true, data, "linux/amd64", "darwin/arm64", "windows/amd64")
+	m.add("go1.24.3", true, data, "linux/amd64", "darwin/arm64", "linux/armv6l", "windows/amd64")
+	m.add("go1.23.10", true, data, "linux/amd64", "darwin/arm64")
+
+	tests := []struct {
+		requested string
+		goos      string
+		arch      string
+		want      string
+		wantFile  string
+	}{
+		{"", "linux", "amd64", "go1.24.4", "go1.24.4.linux-amd64.tar.gz"},
+		{"latest", "darwin", "arm64", "go1.24.4", "go1.24.4.darwin-arm64.tar.gz"},
+		{"1.24", "linux", "amd64", "go1.24.4", "go1.24.4.linux-amd64.tar.gz"},
+		{"go1.23", "linux", "amd64", "go1.23.10", "go1.23.10.linux-amd64.tar.gz"},
+		{"1.24.3", "linux", "amd64", "go1.24.3", "go1.24.3.linux-amd64.tar.gz"},
+		{"go1.25rc1", "linux", "amd64", "go1.25rc1", "go1.25rc1.linux-amd64.tar.gz"},
+		// Only an older release of the series has an archive for arm
+		{"1.24", "linux", "arm", "go1.24.3", "go1.24.3.linux-armv6l.tar.gz"},
+		{"", "windows", "amd64", "go1.24.4", "go1.24.4.windows-amd64.zip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requested+"/"+tt.goos+"-"+tt.arch, func(t *testing.T) {
+			rel, file, err := m.manager(t, tt.goos, tt.arch).Resolve(context.Background(), tt.requested)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rel.Version != tt.want || file.Filename != tt.wantFile {
+				t.Fatalf("Resolve = %s %s, want %s %s", rel.Version, file.Filename, tt.want, tt.wantFile)
+			}
+		})
+	}
+
+	for _, requested := range []string{"1.22", "1.25", "1.24.9", "go1.26rc1"} {
+		if _, _, err := m.manager(t, "linux", "amd64").Resolve(context.Background(), requested); !errors.Is(err, ErrVersionNotFound) {
+			t.Errorf("Resolve(%q) = %v, want ErrVersionNotFound", requested, err)
+		}
+	}
+	if _, _, err := m.manager(t, "plan9", "386").Resolve(context.Background(), ""); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("Resolve for plan9 = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestResolveListingErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/broken") {
+			_, _ = w.Write([]byte("not json"))
+			return
+		}
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	for _, url := range []string{srv.URL + "/down/", srv.URL + "/broken/"} {
+		m := &ToolchainManager{MirrorURL: url, Prefix: t.TempDir(), Client: srv.Client()}
+		if _, _, err := m.Resolve(context.Background(), ""); err == nil {
+			t.Errorf("Resolve against %s succeeded", url)
+		}
+	}
+}
+
+func TestInstall(t *testing.T) {
+	m := newMirror(t)
+	m.add("go1.24.4", true, goArchive(t), "linux/amd64")
+	tm := m.manager(t, "linux", "amd64")
+
+	tc, err := tm.Install(context.Background(), "1.24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(tm.Prefix, "go1.24.4"); tc.GOROOT != want || tc.Version != "go1.24.4" {
+		t.Fatalf("Install = %+v, want GOROOT %s", tc, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(tc.GOROOT, "VERSION")); err != nil || string(data) != "go1.24.4\n" {
+		t.Fatalf("VERSION = %q, %v", data, err)
+	}
+	if link, err := os.Readlink(filepath.Join(tc.GOROOT, "misc", "go")); err != nil || link != "../bin/go" {
+		t.Fatalf("symlink = %q, %v", link, err)
+	}
+	if info, err := os.Stat(filepath.Join(tc.Bin(), "go")); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Fatalf("go command not executable: %v", err)
+	}
+	assertClean(t, tm.Prefix, "go1.24.4")
+
+	// An installed release is reused without downloading it again
+	if _, err := tm.Install(context.Background(), "go1.24.4"); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.downloads.Load(); got != 1 {
+		t.Fatalf("archive downloaded %d times, want 1", got)
+	}
+}
+
+func TestInstallZip(t *testing.T) {
+	m := newMirror(t)
+	m.add("go1.24.4", true, zipArchive(t,
+		entry{name: "go/VERSION", content: "go1.24.4\n"},
+		entry{name: "go/bin/go.exe", content: "MZ"},
+	), "windows/amd64")
+	tm := m.manager(t, "windows", "amd64")
+
+	tc, err := tm.Install(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tm.Installed(tc.Version); !ok {
+		t.Fatal("zip toolchain not reported as installed")
+	}
+	assertClean(t, tm.Prefix, "go1.24.4")
+}
+
+func TestInstallChecksumMismatch(t *testing.T) {
+	m := newMirror(t)
+	m.add("go1.24.4", true, goArchive(t), "linux/amd64")
+	m.archives["go1.24.4.linux-amd64.tar.gz"] = tarGz(t, entry{name: "go/bin/go", content: "tampered"})
+	tm := m.manager(t, "linux", "amd64")
+
+	if _, err := tm.Install(context.Background(), ""); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Install = %v, want ErrChecksumMismatch", err)
+	}
+	assertClean(t, tm.Prefix)
+}
+
+func TestInstallRejectsEscapingEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive func(t *testing.T) []byte
+		goos    string
+	}{
+		{"dot-dot file", func(t *testing.T) []byte {
+			return tarGz(t, entry{name: "go/bin/go", content: "x"}, entry{name: "go/../../evil", content: "x"})
+		}, "linux"},
+		{"absolute symlink", func(t *testing.T) []byte {
+			return tarGz(t, entry{name: "go/bin/go", content: "x"}, entry{name: "go/passwd", link: "/etc/passwd"})
+		}, "linux"},
+		{"escaping symlink", func(t *testing.T) []byte {
+			return tarGz(t, entry{name: "go/bin/go", content: "x"}, entry{name: "go/up", link: "../../outside"})
+		}, "linux"},
+		{"dot-dot zip entry", func(t *testing.T) []byte {
+			return zipArchive(t, entry{name: "go/bin/go.exe", content: "x"}, entry{name: "../evil", content: "x"})
+		}, "windows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMirror(t)
+			m.add("go1.24.4", true, tt.archive(t), tt.goos+"/amd64")
+			tm := m.manager(t, tt.goos, "amd64")
+
+			_, err := tm.Install(context.Background(), "")
+			if err == nil {
+				t.Fatal("Install succeeded")
+			}
+			if !strings.Contains(err.Error(), "escapes") && !strings.Contains(err.Error(), "absolute symlink") {
+				t.Fatalf("Install = %v, want an escaping entry error", err)
+			}
+			assertClean(t, tm.Prefix)
+			if _, err := os.Stat(filepath.Join(filepath.Dir(tm.Prefix), "evil")); !os.IsNotExist(err) {
+				t.Fatalf("escaping entry was written: %v", err)
+			}
+		})
+	}
+}
+
+func TestInstallArchiveWithoutGo(t *testing.T) {
+	m := newMirror(t)
+	m.add("go1.24.4", true, tarGz(t, entry{name: "go/VERSION", content: "go1.24.4\n"}), "linux/amd64")
+	tm := m.manager(t, "linux", "amd64")
+
+	if _, err := tm.Install(context.Background(), ""); err == nil {
+		t.Fatal("Install of an archive without the go command succeeded")
+	}
+}
+
+func TestActivate(t *testing.T) {
+	tc := &Toolchain{Version: "go1.24.4", GOROOT: "/opt/go"}
+	t.Setenv("PATH", "/usr/bin")
+	if err := tc.Activate(); err != nil {
+		t.Fatal(err)
+	}
+	want := "/opt/go/bin" + string(os.PathListSeparator) + "/usr/bin"
+	if got := os.Getenv("PATH"); got != want {
+		t.Fatalf("PATH = %q, want %q", got, want)
+	}
+	if err := tc.Activate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("PATH"); got != want {
+		t.Fatalf("PATH after a second Activate = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultGoVersionMatchesGoMod(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "go "); ok {
+			if v != DefaultGoVersion {
+				t.Fatalf("DefaultGoVersion = %s, go.mod requires %s", DefaultGoVersion, v)
+			}
+			return
+		}
+	}
+	t.Fatal("go.mod has no go directive")
+}
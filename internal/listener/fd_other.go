@@ -0,0 +1,19 @@
+//go:build !unix
+
+package listener
+
+import (
+	"fmt"
+	"os"
+)
+
+// InheritableFDs is not supported on this platform.
+func InheritableFDs(files []*os.File) ([]int, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("listener handoff across exec is not supported on this platform")
+}
+
+// ReleaseFDs is a no-op on this platform.
+func ReleaseFDs(fds []int) {}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package listener
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// InheritableFDs duplicates files into descriptors without close-on-exec so
+// that they survive syscall.Exec, and returns the new descriptor numbers.
+func InheritableFDs(files []*os.File) ([]int, error) {
+	fds := make([]int, 0, len(files))
+	for _, f := range files {
+		fd, err := syscall.Dup(int(f.Fd()))
+		if err != nil {
+			ReleaseFDs(fds)
+			return nil, fmt.Errorf("could not duplicate descriptor for %s: %v", f.Name(), err)
+		}
+		fds = append(fds, fd)
+	}
+	return fds, nil
+}
+
+// ReleaseFDs closes descriptors returned by InheritableFDs.
+func ReleaseFDs(fds []int) {
+	for _, fd := range fds {
+		_ = syscall.Close(fd)
+	}
+}
@@ -0,0 +1,217 @@
+package listener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EnvListenFDs is the environment variable that describes the listeners a
+// restarted process inherits. Each entry has the form fd:network:address and
+// entries are separated by commas.
+const EnvListenFDs = "SELFRESTART_LISTEN_FDS"
+
+// FirstExtraFD is the descriptor number given to the first entry of
+// exec.Cmd.ExtraFiles in the child.
+const FirstExtraFD = 3
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Registry keeps the listeners that must survive a restart.
+type Registry struct {
+	mu        sync.Mutex
+	listeners []net.Listener
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Add registers ln for handoff. Only listeners backed by a file descriptor
+// (TCP and Unix listeners) can be registered.
+func (r *Registry) Add(ln net.Listener) error {
+	if ln == nil {
+		return fmt.Errorf("listener is nil")
+	}
+	if _, ok := ln.(filer); !ok {
+		return fmt.Errorf("listener %s does not expose a file descriptor", ln.Addr())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, ln)
+	return nil
+}
+
+// Len returns the number of registered listeners.
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.listeners)
+}
+
+// Files returns duplicated descriptors for every registered listener, in
+// registration order, together with their addresses. The caller owns the
+// returned files.
+func (r *Registry) Files() ([]*os.File, []net.Addr, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files := make([]*os.File, 0, len(r.listeners))
+	addrs := make([]net.Addr, 0, len(r.listeners))
+	for _, ln := range r.listeners {
+		f, err := ln.(filer).File()
+		if err != nil {
+			CloseFiles(files)
+			return nil, nil, fmt.Errorf("could not get descriptor for %s: %v", ln.Addr(), err)
+		}
+		files = append(files, f)
+		addrs = append(addrs, ln.Addr())
+	}
+	return files, addrs, nil
+}
+
+// Close closes every registered listener so that the current process stops
+// accepting new connections. Descriptors already handed to a child stay open,
+// and so do the paths of Unix sockets, which the child still listens on.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var firstErr error
+	for _, ln := range r.listeners {
+		if ul, ok := ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+		if err := ln.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("could not close listener %s: %v", ln.Addr(), err)
+		}
+	}
+	r.listeners = nil
+	return firstErr
+}
+
+// CloseFiles closes every file in files, ignoring errors.
+func CloseFiles(files []*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
+
+// Encode builds the EnvListenFDs value for listeners available in the child
+// at the given descriptor numbers.
+func Encode(fds []int, addrs []net.Addr) string {
+	entries := make([]string, 0, len(fds))
+	for i, fd := range fds {
+		entries = append(entries, fmt.Sprintf("%d:%s:%s", fd, addrs[i].Network(), addrs[i].String()))
+	}
+	return strings.Join(entries, ",")
+}
+
+// WithEnv returns env with EnvListenFDs set to value, replacing any previous
+// entry. An empty value removes the variable.
+func WithEnv(env []string, value string) []string {
+	out := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, EnvListenFDs+"=") {
+			out = append(out, kv)
+		}
+	}
+	if value != "" {
+		out = append(out, EnvListenFDs+"="+value)
+	}
+	return out
+}
+
+var (
+	inheritOnce sync.Once
+	inherited   []net.Listener
+	inheritErr  error
+	claimedMu   sync.Mutex
+	claimed     = map[net.Listener]bool{}
+)
+
+// Inherited rebuilds the listeners described by EnvListenFDs. The variable is
+// consumed on the first call so that it does not leak into further children;
+// later calls return the same listeners.
+func Inherited() ([]net.Listener, error) {
+	inheritOnce.Do(func() {
+		value := os.Getenv(EnvListenFDs)
+		if value == "" {
+			return
+		}
+		_ = os.Unsetenv(EnvListenFDs)
+		inherited, inheritErr = decode(value)
+	})
+	return inherited, inheritErr
+}
+
+func decode(value string) ([]net.Listener, error) {
+	var listeners []net.Listener
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return listeners, fmt.Errorf("invalid %s entry: %q", EnvListenFDs, entry)
+		}
+		fd, err := strconv.Atoi(parts[0])
+		if err != nil || fd < FirstExtraFD {
+			return listeners, fmt.Errorf("invalid descriptor in %s entry: %q", EnvListenFDs, entry)
+		}
+		f := os.NewFile(uintptr(fd), parts[1]+":"+parts[2])
+		if f == nil {
+			return listeners, fmt.Errorf("descriptor %d is not valid", fd)
+		}
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return listeners, fmt.Errorf("could not rebuild listener %s from descriptor %d: %v", parts[2], fd, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// Take returns the first inherited listener bound to network and address
+// that has not been taken yet, or nil when there is none.
+func Take(network, address string) (net.Listener, error) {
+	listeners, err := Inherited()
+	if err != nil {
+		return nil, err
+	}
+	claimedMu.Lock()
+	defer claimedMu.Unlock()
+	for _, ln := range listeners {
+		if claimed[ln] || !sameAddr(ln.Addr(), network, address) {
+			continue
+		}
+		claimed[ln] = true
+		return ln, nil
+	}
+	return nil, nil
+}
+
+// sameAddr reports whether addr is what listening on network and address
+// would produce. TCP addresses are compared by port and IP, treating an
+// empty host and any unspecified IP as equal.
+func sameAddr(addr net.Addr, network, address string) bool {
+	if addr.String() == address && strings.HasPrefix(network, addr.Network()) {
+		return true
+	}
+	if !strings.HasPrefix(network, "tcp") || addr.Network() != "tcp" {
+		return false
+	}
+	want, err := net.ResolveTCPAddr(network, address)
+	if err != nil {
+		return false
+	}
+	have, ok := addr.(*net.TCPAddr)
+	if !ok || have.Port != want.Port {
+		return false
+	}
+	if want.IP == nil || want.IP.IsUnspecified() {
+		return have.IP == nil || have.IP.IsUnspecified()
+	}
+	return have.IP.Equal(want.IP)
+}
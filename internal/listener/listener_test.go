@@ -0,0 +1,130 @@
+//go:build unix
+
+package listener
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCloseKeepsHandedOffUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRegistry()
+	if err := r.Add(ln); err != nil {
+		t.Fatal(err)
+	}
+	files, addrs, err := r.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || addrs[0].String() != path {
+		t.Fatalf("Files = %d files, addrs %v", len(files), addrs)
+	}
+
+	// What the new process rebuilds from the inherited descriptor
+	inherited, err := net.FileListener(files[0])
+	CloseFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = inherited.Close() }()
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 0 {
+		t.Fatalf("Len after Close = %d", r.Len())
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("socket path removed by Close: %v", err)
+	}
+
+	accepted := make(chan string, 1)
+	go func() {
+		conn, err := inherited.Accept()
+		if err != nil {
+			accepted <- err.Error()
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		data, _ := io.ReadAll(conn)
+		accepted <- string(data)
+	}()
+
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dial after handoff: %v", err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	select {
+	case got := <-accepted:
+		if got != "hello" {
+			t.Fatalf("inherited listener got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("inherited listener accepted nothing")
+	}
+}
+
+func TestAddRejectsListenerWithoutFD(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Add(nil); err == nil {
+		t.Fatal("Add(nil) succeeded")
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	value := Encode([]int{3, 4}, []net.Addr{ln.Addr(), &net.UnixAddr{Name: "/run/a.sock", Net: "unix"}})
+	if want := "3:tcp:" + ln.Addr().String() + ",4:unix:/run/a.sock"; value != want {
+		t.Fatalf("Encode = %q, want %q", value, want)
+	}
+
+	env := WithEnv([]string{"A=1", EnvListenFDs + "=old"}, value)
+	if len(env) != 2 || env[1] != EnvListenFDs+"="+value {
+		t.Fatalf("WithEnv = %q", env)
+	}
+	if env := WithEnv(env, ""); len(env) != 1 || env[0] != "A=1" {
+		t.Fatalf("WithEnv with empty value = %q", env)
+	}
+	for _, bad := range []string{"x", "1:tcp:a", "abc:tcp:a"} {
+		if _, err := decode(bad); err == nil {
+			t.Errorf("decode(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestSameAddr(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4zero, Port: 8080}
+	tests := []struct {
+		network, address string
+		want             bool
+	}{
+		{"tcp", ":8080", true},
+		{"tcp", "0.0.0.0:8080", true},
+		{"tcp4", ":8080", true},
+		{"tcp", ":8081", false},
+		{"tcp", "127.0.0.1:8080", false},
+		{"unix", ":8080", false},
+	}
+	for _, tt := range tests {
+		if got := sameAddr(addr, tt.network, tt.address); got != tt.want {
+			t.Errorf("sameAddr(%s, %s) = %v, want %v", tt.network, tt.address, got, tt.want)
+		}
+	}
+}
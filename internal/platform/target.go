@@ -2,6 +2,7 @@ package platform
 
 import (
 	"fmt"
+	"sync"
 )
 
 type TargetPlatform struct {
@@ -17,6 +18,23 @@ var FullPlatformMap = map[string]TargetPlatform{
 	"linux":   {Installed: false, Version: "", Platform: "linux", Arch: []string{"amd64"}},
 }
 
+// fullPlatformMu guards the Installed and Version fields of FullPlatformMap.
+var fullPlatformMu sync.RWMutex
+
+// SetInstalled records whether Go is installed on platform, and which
+// version, so that GetPlatformTarget reports it.
+func SetInstalled(platform string, installed bool, version string) {
+	fullPlatformMu.Lock()
+	defer fullPlatformMu.Unlock()
+	target, exists := FullPlatformMap[platform]
+	if !exists {
+		return
+	}
+	target.Installed = installed
+	target.Version = version
+	FullPlatformMap[platform] = target
+}
+
 // GetPlatformTarget returns target platform information based on platform and architecture
 func GetPlatformTarget(platform, arch string) (map[string]TargetPlatform, error) {
 	hostInfo := GetHostPlatform()
@@ -28,6 +46,9 @@ func GetPlatformTarget(platform, arch string) (map[string]TargetPlatform, error)
 		arch = hostInfo.Arch
 	}
 
+	fullPlatformMu.RLock()
+	defer fullPlatformMu.RUnlock()
+
 	platformTarget := make(map[string]TargetPlatform)
 
 	if platform == "all" {
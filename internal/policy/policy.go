@@ -0,0 +1,283 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+)
+
+// Mode selects when a process is restarted, after the container runtime
+// policies of the same names.
+type Mode string
+
+const (
+	// Always restarts the process whenever it exits.
+	Always Mode = "always"
+	// OnFailure restarts the process when it exits with a non-zero code or
+	// is killed by a signal.
+	OnFailure Mode = "on-failure"
+	// UnlessStopped restarts the process whenever it exits, except when it
+	// was stopped deliberately.
+	UnlessStopped Mode = "unless-stopped"
+	// Never does not restart the process.
+	Never Mode = "never"
+)
+
+const (
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultResetAfter     = time.Minute
+)
+
+// Policy is a declarative restart policy. It can be loaded from a JSON file,
+// where durations are written as strings such as "1s" or "2m30s".
+type Policy struct {
+	Mode Mode `json:"mode"`
+	// ExitCodes limits OnFailure to these exit codes. Empty means any
+	// non-zero code. Exits caused by signals always count as failures.
+	ExitCodes []int `json:"exit_codes,omitempty"`
+	// MaxAttempts caps the restarts within Window; 0 means unlimited.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// Window is the period MaxAttempts is counted over; 0 counts forever.
+	Window Duration `json:"window,omitempty"`
+	// InitialBackoff is the first restart delay. It doubles on every
+	// consecutive restart up to MaxBackoff.
+	InitialBackoff Duration `json:"initial_backoff,omitempty"`
+	MaxBackoff     Duration `json:"max_backoff,omitempty"`
+	// Jitter adds up to this fraction of the delay at random, from 0 to 1.
+	Jitter float64 `json:"jitter,omitempty"`
+	// ResetAfter resets the backoff when the process ran at least this long.
+	ResetAfter Duration `json:"reset_after,omitempty"`
+}
+
+// Default returns the policy used when none is configured: restart on
+// failure, at most five times a minute, backing off from 1s to 30s.
+func Default() Policy {
+	return Policy{
+		Mode:           OnFailure,
+		MaxAttempts:    5,
+		Window:         Duration(time.Minute),
+		InitialBackoff: Duration(DefaultInitialBackoff),
+		MaxBackoff:     Duration(DefaultMaxBackoff),
+		ResetAfter:     Duration(DefaultResetAfter),
+	}
+}
+
+// Validate reports configuration errors.
+func (p Policy) Validate() error {
+	switch p.Mode {
+	case Always, OnFailure, UnlessStopped, Never:
+	default:
+		return fmt.Errorf("unknown restart policy %q", p.Mode)
+	}
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts must not be negative")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	if p.Window < 0 || p.InitialBackoff < 0 || p.MaxBackoff < 0 || p.ResetAfter < 0 {
+		return fmt.Errorf("durations must not be negative")
+	}
+	return nil
+}
+
+// Load parses a JSON policy. Fields that are not set keep the values of
+// Default, except Mode which must be present.
+func Load(data []byte) (Policy, error) {
+	p := Default()
+	p.Mode = ""
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("could not parse restart policy: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// LoadFile reads a JSON policy from path.
+func LoadFile(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("could not read restart policy: %v", err)
+	}
+	return Load(data)
+}
+
+// Exit describes how a process ended.
+type Exit struct {
+	// Code is the exit code; it is ignored when Signaled is true.
+	Code int
+	// Signaled is true when the process was killed by a signal.
+	Signaled bool
+	// Stopped is true when the process was stopped deliberately, such as by
+	// an operator asking the supervisor to send it SIGTERM. A process that
+	// dies from a signal it was not asked to stop by is not stopped.
+	Stopped bool
+	// Uptime is how long the process ran.
+	Uptime time.Duration
+	// Time is when the exit happened; zero means now.
+	Time time.Time
+}
+
+// Failed reports whether the exit counts as a failure.
+func (e Exit) Failed() bool {
+	return e.Signaled || e.Code != 0
+}
+
+// Decision is the outcome of a policy evaluation.
+type Decision struct {
+	Restart bool
+	Delay   time.Duration
+	// Attempt is the number of restarts within the window, this one included.
+	Attempt int
+	Reason  string
+}
+
+// Tracker applies a policy to a sequence of exits. It is not safe for
+// concurrent use.
+type Tracker struct {
+	policy      Policy
+	attempts    []time.Time
+	consecutive int
+	random      func() float64
+}
+
+func NewTracker(p Policy) *Tracker {
+	return &Tracker{policy: p, random: rand.Float64}
+}
+
+// SetRandom replaces the source of jitter, for deterministic decisions.
+func (t *Tracker) SetRandom(random func() float64) {
+	t.random = random
+}
+
+// Policy returns the tracked policy.
+func (t *Tracker) Policy() Policy {
+	return t.policy
+}
+
+// Reset forgets previous attempts and backoff.
+func (t *Tracker) Reset() {
+	t.attempts = nil
+	t.consecutive = 0
+}
+
+// Decide records exit and tells whether and when to restart.
+func (t *Tracker) Decide(exit Exit) Decision {
+	p := t.policy
+	now := exit.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	switch p.Mode {
+	case Never:
+		return Decision{Reason: "restart policy is never"}
+	case OnFailure:
+		if !exit.Failed() {
+			return Decision{Reason: "process exited successfully"}
+		}
+		if !exit.Signaled && len(p.ExitCodes) > 0 && !containsCode(p.ExitCodes, exit.Code) {
+			return Decision{Reason: fmt.Sprintf("exit code %d is not restartable", exit.Code)}
+		}
+	case UnlessStopped:
+		if exit.Stopped {
+			return Decision{Reason: "process was stopped"}
+		}
+	case Always:
+	default:
+		return Decision{Reason: fmt.Sprintf("unknown restart policy %q", p.Mode)}
+	}
+
+	if p.Window > 0 {
+		kept := t.attempts[:0]
+		for _, at := range t.attempts {
+			if now.Sub(at) < p.Window.Duration() {
+				kept = append(kept, at)
+			}
+		}
+		t.attempts = kept
+	}
+	if p.MaxAttempts > 0 && len(t.attempts) >= p.MaxAttempts {
+		return Decision{Attempt: len(t.attempts), Reason: fmt.Sprintf("reached %d restarts", p.MaxAttempts)}
+	}
+
+	if p.ResetAfter > 0 && exit.Uptime >= p.ResetAfter.Duration() {
+		t.consecutive = 0
+	}
+	delay := t.backoff()
+	t.consecutive++
+	t.attempts = append(t.attempts, now)
+
+	return Decision{Restart: true, Delay: delay, Attempt: len(t.attempts)}
+}
+
+// backoff returns the delay for the next restart.
+func (t *Tracker) backoff() time.Duration {
+	p := t.policy
+	initial := p.InitialBackoff.Duration()
+	if initial <= 0 {
+		return 0
+	}
+	max := p.MaxBackoff.Duration()
+	if max < initial {
+		max = initial
+	}
+	delay := time.Duration(float64(initial) * math.Pow(2, float64(t.consecutive)))
+	if delay > max || delay <= 0 {
+		delay = max
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration(float64(delay) * p.Jitter * t.random())
+	}
+	return delay
+}
+
+func containsCode(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration is a time.Duration that reads and writes as a string in JSON.
+type Duration time.Duration
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts duration strings ("1m30s") and plain numbers of
+// seconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if s := strings.TrimSpace(string(data)); strings.HasPrefix(s, `"`) {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		parsed, err := time.ParseDuration(str)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %v", str, err)
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = Duration(seconds * float64(time.Second))
+	return nil
+}
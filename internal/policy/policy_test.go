@@ -0,0 +1,256 @@
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// at returns a failed exit happening offset after epoch.
+func at(offset time.Duration) Exit {
+	return Exit{Code: 1, Time: epoch.Add(offset)}
+}
+
+func delays(t *Tracker, n int) []time.Duration {
+	var out []time.Duration
+	for i := 0; i < n; i++ {
+		d := t.Decide(at(time.Duration(i) * time.Second))
+		if !d.Restart {
+			return out
+		}
+		out = append(out, d.Delay)
+	}
+	return out
+}
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	tracker := NewTracker(Policy{
+		Mode:           Always,
+		InitialBackoff: Duration(time.Second),
+		MaxBackoff:     Duration(5 * time.Second),
+	})
+	got := delays(tracker, 6)
+	want := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second, 5 * time.Second}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("delays = %v, want %v", got, want)
+	}
+}
+
+func TestBackoffWithoutInitialIsImmediate(t *testing.T) {
+	got := delays(NewTracker(Policy{Mode: Always, MaxBackoff: Duration(time.Minute)}), 3)
+	want := []time.Duration{0, 0, 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("delays = %v, want %v", got, want)
+	}
+}
+
+func TestBackoffMaxBelowInitial(t *testing.T) {
+	got := delays(NewTracker(Policy{
+		Mode:           Always,
+		InitialBackoff: Duration(3 * time.Second),
+		MaxBackoff:     Duration(time.Second),
+	}), 2)
+	want := []time.Duration{3 * time.Second, 3 * time.Second}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("delays = %v, want %v", got, want)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	tracker := NewTracker(Policy{
+		Mode:           Always,
+		InitialBackoff: Duration(time.Second),
+		MaxBackoff:     Duration(10 * time.Second),
+		Jitter:         0.5,
+	})
+	randoms := []float64{0, 0.5, 1}
+	tracker.SetRandom(func() float64 {
+		r := randoms[0]
+		randoms = randoms[1:]
+		return r
+	})
+	got := delays(tracker, 3)
+	want := []time.Duration{time.Second, 2500 * time.Millisecond, 6 * time.Second}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("delays = %v, want %v", got, want)
+	}
+}
+
+func TestMaxAttemptsWithinWindow(t *testing.T) {
+	tracker := NewTracker(Policy{Mode: OnFailure, MaxAttempts: 2, Window: Duration(time.Minute)})
+
+	steps := []struct {
+		offset      time.Duration
+		wantRestart bool
+		wantAttempt int
+	}{
+		{0, true, 1},
+		{time.Second, true, 2},
+		{2 * time.Second, false, 2},
+		// The first attempt falls out of the window
+		{time.Minute, true, 2},
+		{time.Minute + 500*time.Millisecond, false, 2},
+		// Both remaining attempts fall out of the window
+		{3 * time.Minute, true, 1},
+	}
+	for _, step := range steps {
+		d := tracker.Decide(at(step.offset))
+		if d.Restart != step.wantRestart || d.Attempt != step.wantAttempt {
+			t.Fatalf("at %v: Decide = %+v, want restart %v attempt %d", step.offset, d, step.wantRestart, step.wantAttempt)
+		}
+		if !d.Restart && !strings.Contains(d.Reason, "2 restarts") {
+			t.Errorf("at %v: reason %q does not mention the limit", step.offset, d.Reason)
+		}
+	}
+}
+
+func TestMaxAttemptsWithoutWindow(t *testing.T) {
+	tracker := NewTracker(Policy{Mode: Always, MaxAttempts: 3})
+	for i := 0; i < 3; i++ {
+		if d := tracker.Decide(at(time.Duration(i) * time.Hour)); !d.Restart {
+			t.Fatalf("attempt %d refused: %s", i+1, d.Reason)
+		}
+	}
+	if d := tracker.Decide(at(24 * time.Hour)); d.Restart {
+		t.Fatal("fourth attempt allowed without a window")
+	}
+	tracker.Reset()
+	if d := tracker.Decide(at(25 * time.Hour)); !d.Restart || d.Attempt != 1 {
+		t.Fatalf("after Reset: Decide = %+v, want first attempt", d)
+	}
+}
+
+func TestResetAfter(t *testing.T) {
+	tracker := NewTracker(Policy{
+		Mode:           Always,
+		InitialBackoff: Duration(time.Second),
+		MaxBackoff:     Duration(time.Minute),
+		ResetAfter:     Duration(10 * time.Second),
+	})
+
+	exits := []struct {
+		uptime time.Duration
+		want   time.Duration
+	}{
+		{time.Second, time.Second},
+		{time.Second, 2 * time.Second},
+		{9 * time.Second, 4 * time.Second},
+		// Ran long enough: back to the initial delay
+		{10 * time.Second, time.Second},
+		{time.Second, 2 * time.Second},
+	}
+	for i, e := range exits {
+		exit := at(time.Duration(i) * time.Minute)
+		exit.Uptime = e.uptime
+		if d := tracker.Decide(exit); d.Delay != e.want {
+			t.Fatalf("exit %d after %v: delay %v, want %v", i+1, e.uptime, d.Delay, e.want)
+		}
+	}
+}
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  Mode
+		codes []int
+		exit  Exit
+		want  bool
+	}{
+		{"never", Never, nil, Exit{Code: 1}, false},
+		{"always success", Always, nil, Exit{}, true},
+		{"always stopped", Always, nil, Exit{Stopped: true}, true},
+		{"on-failure success", OnFailure, nil, Exit{}, false},
+		{"on-failure code", OnFailure, nil, Exit{Code: 3}, true},
+		{"on-failure signal", OnFailure, nil, Exit{Signaled: true}, true},
+		{"exit code listed", OnFailure, []int{1, 2}, Exit{Code: 2}, true},
+		{"exit code not listed", OnFailure, []int{1, 2}, Exit{Code: 3}, false},
+		{"exit codes ignore signals", OnFailure, []int{1, 2}, Exit{Code: 9, Signaled: true}, true},
+		{"exit codes ignore success", OnFailure, []int{0}, Exit{}, false},
+		{"unless-stopped success", UnlessStopped, nil, Exit{}, true},
+		{"unless-stopped failure", UnlessStopped, nil, Exit{Code: 1}, true},
+		{"unless-stopped stopped", UnlessStopped, nil, Exit{Code: 143, Stopped: true}, false},
+		{"unknown", Mode("sometimes"), nil, Exit{Code: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewTracker(Policy{Mode: tt.mode, ExitCodes: tt.codes}).Decide(tt.exit)
+			if d.Restart != tt.want {
+				t.Fatalf("Decide(%+v) = %+v, want restart %v", tt.exit, d, tt.want)
+			}
+			if !d.Restart && d.Reason == "" {
+				t.Error("refusal without a reason")
+			}
+		})
+	}
+}
+
+func TestRefusalDoesNotCountAttempt(t *testing.T) {
+	tracker := NewTracker(Policy{Mode: OnFailure, MaxAttempts: 1})
+	tracker.Decide(Exit{})
+	if d := tracker.Decide(Exit{Code: 1}); !d.Restart || d.Attempt != 1 {
+		t.Fatalf("Decide = %+v, want first attempt", d)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("Default is invalid: %v", err)
+	}
+	bad := []Policy{
+		{Mode: ""},
+		{Mode: "sometimes"},
+		{Mode: Always, MaxAttempts: -1},
+		{Mode: Always, Jitter: -0.1},
+		{Mode: Always, Jitter: 1.5},
+		{Mode: Always, Window: Duration(-time.Second)},
+		{Mode: Always, MaxBackoff: Duration(-time.Second)},
+	}
+	for _, p := range bad {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", p)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	p, err := Load([]byte(`{"mode": "on-failure", "exit_codes": [1, 2], "window": "2m", "initial_backoff": 0.5, "jitter": 0.2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.ExitCodes = []int{1, 2}
+	want.Window = Duration(2 * time.Minute)
+	want.InitialBackoff = Duration(500 * time.Millisecond)
+	want.Jitter = 0.2
+	if !reflect.DeepEqual(p, want) {
+		t.Fatalf("Load =\n%+v\nwant\n%+v", p, want)
+	}
+
+	for _, data := range []string{
+		`{}`,
+		`{"mode": "always", "window": "soon"}`,
+		`{"mode": "always", "jitter": 2}`,
+		`not json`,
+	} {
+		if _, err := Load([]byte(data)); err == nil {
+			t.Errorf("Load(%s) succeeded", data)
+		}
+	}
+}
+
+func TestDurationJSONRoundTrip(t *testing.T) {
+	data, err := Duration(90 * time.Second).MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"1m30s"` {
+		t.Fatalf("MarshalJSON = %s, want \"1m30s\"", data)
+	}
+	var d Duration
+	if err := d.UnmarshalJSON(data); err != nil || d != Duration(90*time.Second) {
+		t.Fatalf("UnmarshalJSON(%s) = %v, %v", data, d, err)
+	}
+}
@@ -0,0 +1,100 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Match is a process found by FindByName or FindByExecutable.
+type Match struct {
+	PID        int
+	Executable string
+	Args       []string
+}
+
+// FindByName returns the processes whose name, program name or executable
+// base name is name. The current process is never included.
+func (pm *ProcessManager) FindByName(name string) ([]Match, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty process name")
+	}
+	return pm.scan(func(pid int, m Match) bool {
+		if len(m.Args) > 0 && filepath.Base(m.Args[0]) == name {
+			return true
+		}
+		if m.Executable != "" && filepath.Base(m.Executable) == name {
+			return true
+		}
+		comm, err := os.ReadFile(pm.procPath(pid, "comm"))
+		return err == nil && strings.TrimSpace(string(comm)) == name
+	})
+}
+
+// FindByExecutable returns the processes running the binary at path. When
+// the executable of a process may not be read, an absolute program name
+// equal to path also counts. The current process is never included.
+func (pm *ProcessManager) FindByExecutable(path string) ([]Match, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve %s: %v", path, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	return pm.scan(func(pid int, m Match) bool {
+		if m.Executable != "" {
+			return m.Executable == abs
+		}
+		return len(m.Args) > 0 && (m.Args[0] == path || m.Args[0] == abs)
+	})
+}
+
+// scan walks the process entries of the proc root and returns, sorted by
+// PID, those accepted by match. Kernel threads, which have no command
+// line, the current process and those with one of SkipArgs are skipped.
+func (pm *ProcessManager) scan(match func(pid int, m Match) bool) ([]Match, error) {
+	entries, err := os.ReadDir(pm.procRoot())
+	if err != nil {
+		return nil, fmt.Errorf("could not list processes: %v", err)
+	}
+	self := pm.GetCurrentPID()
+	var matches []Match
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		cmdline, err := os.ReadFile(pm.procPath(pid, "cmdline"))
+		if err != nil {
+			continue // Gone since the directory was listed
+		}
+		m := Match{PID: pid, Args: splitNul(cmdline)}
+		if len(m.Args) == 0 || pm.skipped(m.Args) {
+			continue
+		}
+		if exe, err := os.Readlink(pm.procPath(pid, "exe")); err == nil {
+			m.Executable = strings.TrimSuffix(exe, " (deleted)")
+		}
+		if match(pid, m) {
+			matches = append(matches, m)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].PID < matches[j].PID })
+	return matches, nil
+}
+
+// skipped reports whether args hold one of pm.SkipArgs.
+func (pm *ProcessManager) skipped(args []string) bool {
+	for _, arg := range args[1:] {
+		for _, skip := range pm.SkipArgs {
+			if arg == skip {
+				return true
+			}
+		}
+	}
+	return false
+}
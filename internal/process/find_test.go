@@ -0,0 +1,119 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func pids(matches []Match) []int {
+	var out []int
+	for _, m := range matches {
+		out = append(out, m.PID)
+	}
+	return out
+}
+
+func TestFindByName(t *testing.T) {
+	proc := newFakeProc(t)
+	// Matched by comm only
+	proc.cmdline(30, "python3", "worker.py")
+	proc.write(30, "comm", "mysvc\n")
+	// Matched by argv[0]
+	proc.cmdline(20, "/usr/local/bin/mysvc", "--serve")
+	// Matched by the executable, renamed in argv[0]
+	proc.cmdline(10, "svc-main")
+	proc.exe(10, "/opt/mysvc (deleted)")
+	// Not matched: the name only appears in the arguments
+	proc.cmdline(40, "/usr/bin/tail", "-f", "/var/log/mysvc")
+	proc.write(40, "comm", "tail\n")
+	// Kernel thread, no command line
+	proc.write(2, "cmdline", "")
+	proc.write(2, "comm", "mysvc\n")
+	// Current process
+	proc.cmdline(os.Getpid(), "mysvc")
+
+	matches, err := proc.manager().FindByName("mysvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pids(matches), []int{10, 20, 30}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("FindByName PIDs = %v, want %v", got, want)
+	}
+	if matches[0].Executable != "/opt/mysvc" {
+		t.Errorf("Executable = %q, want the (deleted) suffix trimmed", matches[0].Executable)
+	}
+	if want := []string{"/usr/local/bin/mysvc", "--serve"}; !reflect.DeepEqual(matches[1].Args, want) {
+		t.Errorf("Args = %q, want %q", matches[1].Args, want)
+	}
+
+	if _, err := proc.manager().FindByName(""); err == nil {
+		t.Error("FindByName with an empty name succeeded")
+	}
+}
+
+func TestFindByExecutable(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "mysvc")
+	if err := os.WriteFile(bin, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(filepath.Dir(bin), "current")
+	if err := os.Symlink(bin, link); err != nil {
+		t.Fatal(err)
+	}
+
+	proc := newFakeProc(t)
+	proc.cmdline(10, "mysvc")
+	proc.exe(10, bin)
+	// Replaced on disk while running
+	proc.cmdline(11, "mysvc")
+	proc.exe(11, bin+" (deleted)")
+	// Unreadable executable, absolute argv[0]
+	proc.cmdline(12, bin, "--serve")
+	// Same base name, other binary
+	proc.cmdline(13, "mysvc")
+	proc.exe(13, "/usr/bin/mysvc")
+	// Readable executable wins over argv[0]
+	proc.cmdline(14, bin)
+	proc.exe(14, "/usr/bin/env")
+	proc.cmdline(os.Getpid(), bin)
+	proc.exe(os.Getpid(), bin)
+
+	for _, path := range []string{bin, link} {
+		matches, err := proc.manager().FindByExecutable(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := pids(matches), []int{10, 11, 12}; !reflect.DeepEqual(got, want) {
+			t.Errorf("FindByExecutable(%s) PIDs = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestFindSkipArgs(t *testing.T) {
+	proc := newFakeProc(t)
+	proc.cmdline(10, "mysvc", "--serve")
+	proc.cmdline(11, "mysvc", "__helper", "--wait-pid", "10")
+	proc.cmdline(12, "mysvc", "--probe")
+	// Only the arguments are checked, never the program name
+	proc.cmdline(13, "__helper")
+	proc.write(13, "comm", "mysvc\n")
+
+	pm := proc.manager()
+	pm.SkipArgs = []string{"__helper", "--probe"}
+	matches, err := pm.FindByName("mysvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pids(matches), []int{10, 13}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("FindByName PIDs = %v, want %v", got, want)
+	}
+}
+
+func TestFindMissingProcRoot(t *testing.T) {
+	pm := &ProcessManager{ProcRoot: filepath.Join(t.TempDir(), "missing")}
+	if _, err := pm.FindByName("mysvc"); err == nil {
+		t.Fatal("FindByName without a proc root succeeded")
+	}
+}
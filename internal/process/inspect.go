@@ -0,0 +1,150 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultProcRoot is where procfs is mounted.
+const DefaultProcRoot = "/proc"
+
+// clockTicks is USER_HZ, the unit of the times in /proc/<pid>/stat. It is
+// 100 on every architecture Linux supports.
+const clockTicks = 100
+
+// ProcessInfo describes a process as seen in /proc. Fields the caller may
+// not read, such as the environment of another user's process, are left
+// empty; OpenFDs is -1 in that case.
+type ProcessInfo struct {
+	PID        int
+	PPID       int
+	Name       string
+	State      string
+	Executable string
+	Args       []string
+	Env        []string
+	StartTime  time.Time
+	Uptime     time.Duration
+	// RSS is the resident set size in bytes.
+	RSS     int64
+	Threads int
+	OpenFDs int
+}
+
+// procRoot returns the configured procfs mount point.
+func (pm *ProcessManager) procRoot() string {
+	if pm.ProcRoot == "" {
+		return DefaultProcRoot
+	}
+	return pm.ProcRoot
+}
+
+// procPath returns the path of name under the /proc entry of pid.
+func (pm *ProcessManager) procPath(pid int, name string) string {
+	return filepath.Join(pm.procRoot(), strconv.Itoa(pid), name)
+}
+
+// Inspect reads stat, status, cmdline, exe, environ and fd under the /proc
+// entry of pid.
+func (pm *ProcessManager) Inspect(pid int) (ProcessInfo, error) {
+	info := ProcessInfo{PID: pid, OpenFDs: -1}
+	if pid <= 0 {
+		return info, fmt.Errorf("invalid PID: %d", pid)
+	}
+
+	stat, err := os.ReadFile(pm.procPath(pid, "stat"))
+	if err != nil {
+		return info, fmt.Errorf("could not inspect process %d: %w", pid, err)
+	}
+	fields := parseStat(stat)
+	// Fields 4 (ppid), 20 (num_threads) and 22 (starttime) of proc(5),
+	// counted from the state, which is field 3
+	if len(fields) < 20 {
+		return info, fmt.Errorf("could not parse stat of process %d", pid)
+	}
+	info.State = fields[0]
+	info.PPID, _ = strconv.Atoi(fields[1])
+	info.Threads, _ = strconv.Atoi(fields[17])
+	if ticks, err := strconv.ParseInt(fields[19], 10, 64); err == nil {
+		if boot, err := pm.bootTime(); err == nil {
+			info.StartTime = boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+			info.Uptime = time.Since(info.StartTime)
+		}
+	}
+
+	if status, err := os.ReadFile(pm.procPath(pid, "status")); err == nil {
+		pm.parseStatus(status, &info)
+	}
+	if cmdline, err := os.ReadFile(pm.procPath(pid, "cmdline")); err == nil {
+		info.Args = splitNul(cmdline)
+	}
+	if environ, err := os.ReadFile(pm.procPath(pid, "environ")); err == nil {
+		info.Env = splitNul(environ)
+	}
+	if exe, err := os.Readlink(pm.procPath(pid, "exe")); err == nil {
+		info.Executable = strings.TrimSuffix(exe, " (deleted)")
+	}
+	if fds, err := os.ReadDir(pm.procPath(pid, "fd")); err == nil {
+		info.OpenFDs = len(fds)
+	}
+	return info, nil
+}
+
+// parseStatus fills the fields of info found in /proc/<pid>/status, which
+// are more descriptive than their counterparts in stat.
+func (pm *ProcessManager) parseStatus(status []byte, info *ProcessInfo) {
+	scanner := bufio.NewScanner(bytes.NewReader(status))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Name":
+			info.Name = value
+		case "State":
+			info.State = value
+		case "Threads":
+			info.Threads, _ = strconv.Atoi(value)
+		case "VmRSS":
+			if kb, err := strconv.ParseInt(strings.TrimSuffix(value, " kB"), 10, 64); err == nil {
+				info.RSS = kb * 1024
+			}
+		}
+	}
+}
+
+// bootTime reads the boot time from the btime line of <procroot>/stat.
+func (pm *ProcessManager) bootTime() (time.Time, error) {
+	stat, err := os.ReadFile(filepath.Join(pm.procRoot(), "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(stat))
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no btime in %s", filepath.Join(pm.procRoot(), "stat"))
+}
+
+// splitNul splits the NUL-separated contents of cmdline and environ.
+func splitNul(data []byte) []string {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(string(data), "\x00")
+}
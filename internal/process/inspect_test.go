@@ -0,0 +1,157 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProc is a proc tree under t.TempDir() for a ProcessManager to read.
+type fakeProc struct {
+	t    *testing.T
+	root string
+}
+
+func newFakeProc(t *testing.T) *fakeProc {
+	t.Helper()
+	return &fakeProc{t: t, root: t.TempDir()}
+}
+
+func (p *fakeProc) manager() *ProcessManager {
+	return &ProcessManager{ProcRoot: p.root}
+}
+
+// write creates name under the entry of pid, or under the root when pid is 0.
+func (p *fakeProc) write(pid int, name, content string) {
+	p.t.Helper()
+	dir := p.root
+	if pid != 0 {
+		dir = filepath.Join(p.root, fmt.Sprint(pid))
+	}
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		p.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// stat writes a stat file for pid with the given comm, state, parent,
+// thread count and start time in clock ticks.
+func (p *fakeProc) stat(pid int, comm, state string, ppid, threads int, start int64) {
+	p.t.Helper()
+	p.write(pid, "stat", fmt.Sprintf(
+		"%d (%s) %s %d %d %d 0 -1 4194560 100 0 0 0 5 3 0 0 20 0 %d 0 %d 1000000 200 18446744073709551615\n",
+		pid, comm, state, ppid, pid, pid, threads, start))
+}
+
+func (p *fakeProc) cmdline(pid int, args ...string) {
+	p.t.Helper()
+	p.write(pid, "cmdline", strings.Join(args, "\x00")+"\x00")
+}
+
+func (p *fakeProc) exe(pid int, target string) {
+	p.t.Helper()
+	dir := filepath.Join(p.root, fmt.Sprint(pid))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		p.t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(dir, "exe")); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	proc := newFakeProc(t)
+	proc.write(0, "stat", "cpu  1 2 3 4\nbtime 1700000000\nprocesses 42\n")
+	proc.stat(4321, "my (odd) app)", "S", 1, 3, 250)
+	proc.write(4321, "status", "Name:\tmy (odd) app)\nState:\tS (sleeping)\nPPid:\t1\nVmRSS:\t    2048 kB\nThreads:\t4\n")
+	proc.cmdline(4321, "/opt/app/bin/app", "--port", "8080")
+	proc.write(4321, "environ", "HOME=/root\x00LANG=C\x00")
+	proc.exe(4321, "/opt/app/bin/app (deleted)")
+	for _, fd := range []string{"0", "1", "2", "5"} {
+		proc.write(4321, filepath.Join("fd", fd), "")
+	}
+
+	info, err := proc.manager().Inspect(4321)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ProcessInfo{
+		PID:        4321,
+		PPID:       1,
+		Name:       "my (odd) app)",
+		State:      "S (sleeping)",
+		Executable: "/opt/app/bin/app",
+		Args:       []string{"/opt/app/bin/app", "--port", "8080"},
+		Env:        []string{"HOME=/root", "LANG=C"},
+		StartTime:  time.Unix(1700000002, int64(500*time.Millisecond)),
+		RSS:        2048 * 1024,
+		Threads:    4,
+		OpenFDs:    4,
+	}
+	if info.Uptime <= 0 {
+		t.Errorf("Uptime = %v, want positive", info.Uptime)
+	}
+	info.Uptime = 0
+	if !reflect.DeepEqual(info, want) {
+		t.Fatalf("Inspect =\n%+v\nwant\n%+v", info, want)
+	}
+}
+
+func TestInspectStatOnly(t *testing.T) {
+	proc := newFakeProc(t)
+	proc.stat(77, "worker", "R", 12, 2, 100)
+
+	info, err := proc.manager().Inspect(77)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ProcessInfo{PID: 77, PPID: 12, State: "R", Threads: 2, OpenFDs: -1}
+	if !reflect.DeepEqual(info, want) {
+		t.Fatalf("Inspect =\n%+v\nwant\n%+v", info, want)
+	}
+}
+
+func TestInspectErrors(t *testing.T) {
+	proc := newFakeProc(t)
+	proc.write(9, "stat", "9 (short) S 1\n")
+	pm := proc.manager()
+
+	for _, pid := range []int{0, -1, 8, 9} {
+		info, err := pm.Inspect(pid)
+		if err == nil {
+			t.Errorf("Inspect(%d) succeeded", pid)
+		}
+		if info.OpenFDs != -1 {
+			t.Errorf("Inspect(%d).OpenFDs = %d, want -1", pid, info.OpenFDs)
+		}
+	}
+}
+
+func TestParseStat(t *testing.T) {
+	got := parseStat([]byte("10 (a) b) c) Z 3 10\n"))
+	want := []string{"Z", "3", "10"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseStat = %q, want %q", got, want)
+	}
+}
+
+func TestIsZombieAndParentPID(t *testing.T) {
+	proc := newFakeProc(t)
+	proc.stat(20, "dead", "Z", 19, 1, 0)
+	proc.stat(21, "live", "S", 19, 1, 0)
+	pm := proc.manager()
+
+	if !pm.isZombie(20) || pm.isZombie(21) || pm.isZombie(22) {
+		t.Fatal("isZombie does not follow the stat state")
+	}
+	if ppid, err := pm.parentPID(21); err != nil || ppid != 19 {
+		t.Fatalf("parentPID(21) = %d, %v; want 19", ppid, err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package process
+
+import (
+	"os"
+)
+
+// lockFile is a no-op where flock is not available; AcquirePIDFile still
+// refuses to start when the recorded PID is alive.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// fileOwner reports no owner where files have no UID.
+func fileOwner(info os.FileInfo) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,207 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HandoffTimeout bounds how long AcquirePIDFile waits for a parent that
+// restarted us to release the pidfile.
+const HandoffTimeout = 10 * time.Second
+
+var (
+	// ErrAlreadyRunning is returned when another live process holds the pidfile.
+	ErrAlreadyRunning = errors.New("another instance is already running")
+	// ErrStalePIDFile is returned when a pidfile names a process that is gone.
+	ErrStalePIDFile = errors.New("stale pidfile")
+)
+
+// PIDDir returns the directory holding the pidfiles of named services:
+// $XDG_RUNTIME_DIR/selfrestart when set, or selfrestart-<uid> under the temp
+// dir so that users do not share it.
+func PIDDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "selfrestart")
+	}
+	if uid := os.Getuid(); uid >= 0 {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("selfrestart-%d", uid))
+	}
+	return filepath.Join(os.TempDir(), "selfrestart")
+}
+
+// checkPIDDir refuses a PIDDir that another user could have created or may
+// write to, since its pidfiles decide which processes get signalled.
+func checkPIDDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("could not check pidfile directory: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("pidfile directory %s is not a directory", dir)
+	}
+	if uid, ok := fileOwner(info); ok && uid != os.Getuid() {
+		return fmt.Errorf("pidfile directory %s is owned by UID %d, not by the current user", dir, uid)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("pidfile directory %s has mode %v, want 0700", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+// PIDFilePath returns the pidfile of the service called name.
+func PIDFilePath(name string) string {
+	return filepath.Join(PIDDir(), name+".pid")
+}
+
+// PIDFile is a pidfile owned by the current process. A companion lock file
+// is held with flock for as long as the process lives, so that a second
+// instance is refused even while the pidfile itself is being replaced.
+type PIDFile struct {
+	path string
+	lock *os.File
+}
+
+// AcquirePIDFile locks the pidfile at path and writes pid into it. It fails
+// with ErrAlreadyRunning when a live process holds the lock. A pidfile left
+// behind by a dead process is replaced. When the holder is our own parent,
+// as after a restart with a readiness handshake, it waits up to
+// HandoffTimeout for the parent to exit.
+func (pm *ProcessManager) AcquirePIDFile(path string, pid int) (*PIDFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("could not create pidfile directory: %v", err)
+	}
+	if filepath.Dir(path) == PIDDir() {
+		if err := checkPIDDir(filepath.Dir(path)); err != nil {
+			return nil, err
+		}
+	}
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %v", err)
+	}
+	err = lockFile(lock)
+	parent := os.Getppid()
+	if owner, readErr := readPID(path); err != nil && readErr == nil && owner == parent {
+		deadline := time.Now().Add(HandoffTimeout)
+		for err != nil && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+			err = lockFile(lock)
+		}
+	}
+	if err != nil {
+		_ = lock.Close()
+		if owner, readErr := readPID(path); readErr == nil {
+			return nil, fmt.Errorf("%w (PID %d, pidfile %s)", ErrAlreadyRunning, owner, path)
+		}
+		return nil, fmt.Errorf("%w (pidfile %s): %v", ErrAlreadyRunning, path, err)
+	}
+
+	if old, err := readPID(path); err == nil && old != pid && old != parent {
+		if running, _ := pm.IsProcessRunning(old); running {
+			_ = lock.Close()
+			return nil, fmt.Errorf("%w (PID %d, pidfile %s)", ErrAlreadyRunning, old, path)
+		}
+	}
+
+	pf := &PIDFile{path: path, lock: lock}
+	if err := pf.Update(pid); err != nil {
+		_ = lock.Close()
+		return nil, err
+	}
+	return pf, nil
+}
+
+// Path returns the pidfile path.
+func (pf *PIDFile) Path() string {
+	return pf.path
+}
+
+// Update atomically replaces the pidfile content with pid.
+func (pf *PIDFile) Update(pid int) error {
+	tmp, err := os.CreateTemp(filepath.Dir(pf.path), "."+filepath.Base(pf.path)+".*")
+	if err != nil {
+		return fmt.Errorf("could not create pidfile: %v", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := fmt.Fprintf(tmp, "%d\n", pid); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("could not write pidfile: %v", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("could not set pidfile permissions: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write pidfile: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), pf.path); err != nil {
+		return fmt.Errorf("could not replace pidfile: %v", err)
+	}
+	return nil
+}
+
+// Release removes the pidfile and drops the lock. The lock file itself is
+// kept so that every instance always locks the same inode.
+func (pf *PIDFile) Release() error {
+	err := os.Remove(pf.path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if closeErr := pf.lock.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ReadPIDFile returns the PID recorded at path. When that process is no
+// longer running ErrStalePIDFile is returned, and the stale pidfile is
+// removed if its lock can be taken: a held lock means an instance is just
+// starting and about to rewrite it.
+func (pm *ProcessManager) ReadPIDFile(path string) (int, error) {
+	if filepath.Dir(path) == PIDDir() {
+		if err := checkPIDDir(filepath.Dir(path)); err != nil {
+			return 0, err
+		}
+	}
+	pid, err := readPID(path)
+	if err != nil {
+		return 0, err
+	}
+	if running, _ := pm.IsProcessRunning(pid); running {
+		return pid, nil
+	}
+
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err == nil {
+		if lockFile(lock) == nil {
+			// Checked again under the lock, the pidfile may have been replaced
+			if current, err := readPID(path); err == nil && current == pid {
+				_ = os.Remove(path)
+			}
+		} else if current, err := readPID(path); err == nil && current != pid {
+			if running, _ := pm.IsProcessRunning(current); running {
+				_ = lock.Close()
+				return current, nil
+			}
+		}
+		_ = lock.Close()
+	}
+	return 0, fmt.Errorf("%w: %s names PID %d, which is not running", ErrStalePIDFile, path, pid)
+}
+
+func readPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not read pidfile: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pidfile %s", path)
+	}
+	return pid, nil
+}
@@ -0,0 +1,160 @@
+//go:build unix
+
+package process
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// deadPID returns the PID of a process that has exited and been reaped.
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("could not run true: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func writePID(t *testing.T, path string, pid int) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strconv.Itoa(pid)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadPIDFile(t *testing.T) {
+	pm := NewProcessManager()
+	path := filepath.Join(t.TempDir(), "svc.pid")
+	writePID(t, path, os.Getpid())
+
+	pid, err := pm.ReadPIDFile(path)
+	if err != nil || pid != os.Getpid() {
+		t.Fatalf("ReadPIDFile = %d, %v; want %d", pid, err, os.Getpid())
+	}
+}
+
+func TestReadPIDFileRemovesStale(t *testing.T) {
+	pm := NewProcessManager()
+	path := filepath.Join(t.TempDir(), "svc.pid")
+	writePID(t, path, deadPID(t))
+
+	if _, err := pm.ReadPIDFile(path); !errors.Is(err, ErrStalePIDFile) {
+		t.Fatalf("ReadPIDFile = %v, want ErrStalePIDFile", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("stale pidfile kept: %v", err)
+	}
+}
+
+func TestReadPIDFileKeepsLockedStale(t *testing.T) {
+	pm := NewProcessManager()
+	path := filepath.Join(t.TempDir(), "svc.pid")
+	writePID(t, path, deadPID(t))
+
+	// A starting instance holds the lock before rewriting the pidfile
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lock.Close() }()
+	if err := lockFile(lock); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pm.ReadPIDFile(path); !errors.Is(err, ErrStalePIDFile) {
+		t.Fatalf("ReadPIDFile = %v, want ErrStalePIDFile", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("locked pidfile removed: %v", err)
+	}
+}
+
+func TestAcquirePIDFile(t *testing.T) {
+	pm := NewProcessManager()
+	path := filepath.Join(t.TempDir(), "svc.pid")
+
+	pf, err := pm.AcquirePIDFile(path, os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pm.AcquirePIDFile(path, os.Getpid()); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second AcquirePIDFile = %v, want ErrAlreadyRunning", err)
+	}
+	if pid, err := pm.ReadPIDFile(path); err != nil || pid != os.Getpid() {
+		t.Fatalf("ReadPIDFile = %d, %v", pid, err)
+	}
+	if err := pf.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("pidfile kept after Release: %v", err)
+	}
+
+	writePID(t, path, deadPID(t))
+	pf, err = pm.AcquirePIDFile(path, os.Getpid())
+	if err != nil {
+		t.Fatalf("AcquirePIDFile over a stale pidfile: %v", err)
+	}
+	_ = pf.Release()
+}
+
+func TestPIDDir(t *testing.T) {
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	if got, want := PIDDir(), filepath.Join(runtime, "selfrestart"); got != want {
+		t.Fatalf("PIDDir = %s, want %s", got, want)
+	}
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	want := filepath.Join(os.TempDir(), "selfrestart-"+strconv.Itoa(os.Getuid()))
+	if got := PIDDir(); got != want {
+		t.Fatalf("PIDDir without XDG_RUNTIME_DIR = %s, want %s", got, want)
+	}
+}
+
+func TestPIDDirMustBePrivate(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", tmp)
+	dir := PIDDir()
+	if err := os.Mkdir(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	pm := NewProcessManager()
+	path := PIDFilePath("svc")
+
+	if _, err := pm.AcquirePIDFile(path, os.Getpid()); err == nil || !strings.Contains(err.Error(), "mode") {
+		t.Fatalf("AcquirePIDFile in a shared directory = %v, want a mode error", err)
+	}
+	writePID(t, path, os.Getpid())
+	if _, err := pm.ReadPIDFile(path); err == nil {
+		t.Fatal("ReadPIDFile in a shared directory succeeded")
+	}
+
+	if err := os.Chmod(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if pid, err := pm.ReadPIDFile(path); err != nil || pid != os.Getpid() {
+		t.Fatalf("ReadPIDFile = %d, %v", pid, err)
+	}
+
+	// A symlink planted in place of the directory is refused as well
+	if err := os.Rename(dir, filepath.Join(tmp, "real")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(tmp, "real"), dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pm.ReadPIDFile(path); err == nil {
+		t.Fatal("ReadPIDFile through a symlinked directory succeeded")
+	}
+}
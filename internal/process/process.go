@@ -1,13 +1,21 @@
 package process
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"syscall"
-	"time"
 )
 
-type ProcessManager struct{}
+type ProcessManager struct {
+	// ProcRoot is where procfs is mounted; empty means DefaultProcRoot.
+	ProcRoot string
+	// SkipArgs leaves out of FindByName and FindByExecutable the processes
+	// with any of these arguments, such as restart helpers and probes.
+	SkipArgs []string
+}
 
 func NewProcessManager() *ProcessManager {
 	return &ProcessManager{}
@@ -17,44 +25,15 @@ func (pm *ProcessManager) GetCurrentPID() int {
 	return os.Getpid()
 }
 
+// KillCurrentProcess interrupts the current process and kills it when it is
+// still running after DefaultTerminateTimeout. It only returns when the
+// interrupt is handled without exiting and the kill cannot be sent.
 func (pm *ProcessManager) KillCurrentProcess() error {
-	pid := pm.GetCurrentPID()
-	if pid <= 0 {
-		return fmt.Errorf("invalid PID: %d", pid)
-	}
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return fmt.Errorf("could not find process %d: %v", pid, err)
-	}
-	if err := process.Signal(os.Interrupt); err != nil {
-		return fmt.Errorf("could not send interrupt to process %d: %v", pid, err)
-	}
-	if err := process.Release(); err != nil {
-		return fmt.Errorf("could not release process %d: %v", pid, err)
-	}
-	deadline := time.Now().Add(10 * time.Second)
-	sleepTicker := time.NewTicker(100 * time.Millisecond)
-	beautySleep := 50 * time.Millisecond
-	for {
-		select {
-		case <-time.After(deadline.Sub(time.Now())):
-			if _, err := os.FindProcess(pid); err != nil {
-				return fmt.Errorf("process %d did not terminate after interrupt", pid)
-			}
-		case <-sleepTicker.C:
-			if _, err := os.FindProcess(pid); err != nil {
-				return nil
-			}
-			if time.Now().After(deadline) {
-				return fmt.Errorf("process %d did not terminate after interrupt", pid)
-			}
-			if _, err := os.FindProcess(pid); err != nil {
-				return nil
-			}
-		default:
-			time.Sleep(beautySleep)
-		}
-	}
+	_, err := pm.Terminate(pm.GetCurrentPID(), TerminateOptions{Steps: []Step{
+		{Signal: syscall.SIGINT, Timeout: DefaultTerminateTimeout},
+		{Signal: syscall.SIGKILL, Timeout: DefaultKillTimeout},
+	}})
+	return err
 }
 
 func (pm *ProcessManager) IsProcessRunning(pid int) (bool, error) {
@@ -72,6 +51,54 @@ func (pm *ProcessManager) IsProcessRunning(pid int) (bool, error) {
 	if err := process.Signal(syscall.Signal(0)); err != nil {
 		return false, nil // Process doesn't exist or we don't have permission
 	}
-	
-	return true, nil
+
+	// A zombie still answers signal 0 but has already exited
+	return !pm.isZombie(pid), nil
+}
+
+// isZombie reports whether /proc shows pid as exited but not yet reaped.
+func (pm *ProcessManager) isZombie(pid int) bool {
+	fields, err := pm.statFields(pid)
+	return err == nil && len(fields) > 0 && fields[0] == "Z"
+}
+
+// parentPID returns the parent of pid as recorded in /proc.
+func (pm *ProcessManager) parentPID(pid int) (int, error) {
+	fields, err := pm.statFields(pid)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("could not parse stat of process %d", pid)
+	}
+	return strconv.Atoi(fields[1])
+}
+
+// statFields returns the fields of /proc/<pid>/stat that follow the command
+// name, starting with the state.
+func (pm *ProcessManager) statFields(pid int) ([]string, error) {
+	stat, err := os.ReadFile(pm.procPath(pid, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	return parseStat(stat), nil
+}
+
+// parseStat splits the contents of a stat file after the command name.
+func parseStat(stat []byte) []string {
+	// The command name is wrapped in parentheses and may contain spaces
+	return strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+}
+
+// ExecutablePath returns the executable of pid. Other processes are resolved
+// through /proc, so it only works for them where procfs is available.
+func (pm *ProcessManager) ExecutablePath(pid int) (string, error) {
+	if pid == pm.GetCurrentPID() {
+		return os.Executable()
+	}
+	exe, err := os.Readlink(pm.procPath(pid, "exe"))
+	if err != nil {
+		return "", fmt.Errorf("could not resolve executable of process %d: %v", pid, err)
+	}
+	return strings.TrimSuffix(exe, " (deleted)"), nil
 }
@@ -0,0 +1,206 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Exit detection methods reported in TerminateResult.Method.
+const (
+	// MethodPidfd waits on a pidfd, which also makes the signals immune to
+	// PID reuse. Linux 5.3 and later.
+	MethodPidfd = "pidfd"
+	// MethodWait waits for a child of the current process without reaping it.
+	MethodWait = "wait"
+	// MethodSignal0 polls the process with signal 0.
+	MethodSignal0 = "signal0"
+)
+
+const (
+	// DefaultTerminateTimeout is how long the process gets to exit after SIGTERM.
+	DefaultTerminateTimeout = 10 * time.Second
+	// DefaultKillTimeout is how long the exit is awaited after SIGKILL.
+	DefaultKillTimeout = 5 * time.Second
+	// DefaultPollInterval is how often MethodSignal0 and MethodWait check the
+	// process.
+	DefaultPollInterval = 100 * time.Millisecond
+)
+
+// ErrStillRunning is returned when the process outlived every step.
+var ErrStillRunning = errors.New("process is still running")
+
+// Step is one signal of a termination sequence and how long to wait for the
+// process to exit afterwards. A zero or negative Timeout checks once without
+// waiting.
+type Step struct {
+	Signal  syscall.Signal
+	Timeout time.Duration
+}
+
+// DefaultSteps sends SIGTERM and, when the process is still running after
+// DefaultTerminateTimeout, SIGKILL.
+func DefaultSteps() []Step {
+	return []Step{
+		{Signal: syscall.SIGTERM, Timeout: DefaultTerminateTimeout},
+		{Signal: syscall.SIGKILL, Timeout: DefaultKillTimeout},
+	}
+}
+
+// TerminateOptions configures Terminate.
+type TerminateOptions struct {
+	// Steps is the signal sequence; nil means DefaultSteps().
+	Steps []Step
+	// PollInterval applies when the exit has to be polled; zero means
+	// DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+// TerminateResult describes what Terminate did.
+type TerminateResult struct {
+	PID int
+	// Exited reports whether the process was seen exiting, including when it
+	// was already gone.
+	Exited bool
+	// Signals lists the signals sent, in order.
+	Signals []syscall.Signal
+	// Method is how the exit was detected.
+	Method string
+	// Elapsed is the time from the first signal until the exit or the end of
+	// the last step.
+	Elapsed time.Duration
+}
+
+// exitWatcher signals one process and waits for it to exit.
+type exitWatcher interface {
+	signal(sig syscall.Signal) error
+	// wait reports whether the process exited within timeout.
+	wait(timeout time.Duration) (bool, error)
+	method() string
+	close()
+}
+
+// Terminate sends the signals of opts.Steps to pid in order, waiting after
+// each one for the process to exit, and stops as soon as it does. Children
+// of the current process are not reaped, so their owner still gets the exit
+// status. When the process survives every step the result comes with an
+// error wrapping ErrStillRunning.
+func (pm *ProcessManager) Terminate(pid int, opts TerminateOptions) (*TerminateResult, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("invalid PID: %d", pid)
+	}
+	steps := opts.Steps
+	if steps == nil {
+		steps = DefaultSteps()
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	w := newExitWatcher(pm, pid, interval)
+	defer w.close()
+	return terminate(w, pid, steps)
+}
+
+// terminate runs steps against pid through w.
+func terminate(w exitWatcher, pid int, steps []Step) (*TerminateResult, error) {
+	res := &TerminateResult{PID: pid, Method: w.method()}
+	if gone, err := w.wait(0); err != nil {
+		return res, err
+	} else if gone {
+		res.Exited = true
+		return res, nil
+	}
+
+	start := time.Now()
+	for _, step := range steps {
+		if err := w.signal(step.Signal); err != nil {
+			if errors.Is(err, syscall.ESRCH) {
+				res.Exited = true
+				break
+			}
+			return res, fmt.Errorf("could not send %v to process %d: %v", step.Signal, pid, err)
+		}
+		res.Signals = append(res.Signals, step.Signal)
+		gone, err := w.wait(step.Timeout)
+		if err != nil {
+			return res, err
+		}
+		if gone {
+			res.Exited = true
+			break
+		}
+	}
+	res.Elapsed = time.Since(start)
+	if !res.Exited {
+		return res, fmt.Errorf("process %d did not terminate: %w", pid, ErrStillRunning)
+	}
+	return res, nil
+}
+
+// pollWatcher detects the exit with signal 0, which works for any process
+// the caller may signal.
+type pollWatcher struct {
+	pm       *ProcessManager
+	pid      int
+	interval time.Duration
+}
+
+func (w *pollWatcher) signal(sig syscall.Signal) error {
+	p, err := os.FindProcess(w.pid)
+	if err != nil {
+		return err
+	}
+	if err := p.Signal(sig); err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return syscall.ESRCH
+		}
+		return err
+	}
+	return nil
+}
+
+func (w *pollWatcher) wait(timeout time.Duration) (bool, error) {
+	return poll(timeout, w.interval, func() (bool, error) {
+		// Unlike IsProcessRunning, EPERM means the process exists but
+		// belongs to someone else, so it is not taken as an exit
+		err := w.signal(0)
+		switch {
+		case errors.Is(err, syscall.ESRCH):
+			return true, nil
+		case err != nil && !errors.Is(err, syscall.EPERM):
+			return false, err
+		}
+		return w.pm.isZombie(w.pid), nil
+	})
+}
+
+func (w *pollWatcher) method() string {
+	return MethodSignal0
+}
+
+func (w *pollWatcher) close() {}
+
+// poll calls done every interval until it returns true or timeout expires.
+// done is always called at least once.
+func poll(timeout, interval time.Duration, done func() (bool, error)) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := done()
+		if ok || err != nil {
+			return ok, err
+		}
+		left := time.Until(deadline)
+		if left <= 0 {
+			return false, nil
+		}
+		if left < interval {
+			time.Sleep(left)
+		} else {
+			time.Sleep(interval)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+//go:build linux
+
+package process
+
+import (
+	"bufio"
+	"errors"
+	"os/exec"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startChild runs script under sh and waits until it prints its first line,
+// so that traps are in place before the test signals it.
+func startChild(t *testing.T, script string) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", script)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("could not start sh: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	if _, err := bufio.NewReader(out).ReadString('\n'); err != nil {
+		t.Fatalf("child did not report ready: %v", err)
+	}
+	return cmd
+}
+
+// sleeper ignores nothing and exits on SIGTERM.
+const sleeper = "echo ready; exec sleep 30"
+
+// stubborn ignores SIGTERM; the disposition survives the exec.
+const stubborn = "trap '' TERM; echo ready; exec sleep 30"
+
+// watchers returns every exit watcher that can follow a child of the test.
+func watchers(t *testing.T, pm *ProcessManager, pid int) map[string]exitWatcher {
+	t.Helper()
+	poll := &pollWatcher{pm: pm, pid: pid, interval: 10 * time.Millisecond}
+	ws := map[string]exitWatcher{
+		MethodSignal0: poll,
+		MethodWait:    &childWatcher{pollWatcher: poll},
+	}
+	if w := newExitWatcher(pm, pid, poll.interval); w.method() == MethodPidfd {
+		ws[MethodPidfd] = w
+		t.Cleanup(w.close)
+	}
+	return ws
+}
+
+// waitSignal reaps cmd and returns the signal that killed it. It fails when
+// the child had already been reaped by someone else.
+func waitSignal(t *testing.T, cmd *exec.Cmd) syscall.Signal {
+	t.Helper()
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Wait = %v, want the exit status of the child", err)
+	}
+	ws, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		t.Fatalf("child exited with %v, want a signal", exitErr)
+	}
+	return ws.Signal()
+}
+
+func TestTerminate(t *testing.T) {
+	pm := NewProcessManager()
+	cmd := startChild(t, sleeper)
+
+	res, err := pm.Terminate(cmd.Process.Pid, TerminateOptions{PollInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Exited || !reflect.DeepEqual(res.Signals, []syscall.Signal{syscall.SIGTERM}) {
+		t.Fatalf("Terminate = %+v, want an exit after SIGTERM", res)
+	}
+	// Terminate picks the watcher; whichever it is, the status is left to Wait
+	if sig := waitSignal(t, cmd); sig != syscall.SIGTERM {
+		t.Fatalf("child killed by %v, want SIGTERM", sig)
+	}
+}
+
+func TestTerminateEscalatesToKill(t *testing.T) {
+	pm := NewProcessManager()
+	for _, method := range []string{MethodPidfd, MethodWait, MethodSignal0} {
+		t.Run(method, func(t *testing.T) {
+			cmd := startChild(t, stubborn)
+			w, ok := watchers(t, pm, cmd.Process.Pid)[method]
+			if !ok {
+				t.Skipf("%s is not available", method)
+			}
+			steps := []Step{
+				{Signal: syscall.SIGTERM, Timeout: 200 * time.Millisecond},
+				{Signal: syscall.SIGKILL, Timeout: 5 * time.Second},
+			}
+
+			res, err := terminate(w, cmd.Process.Pid, steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL}
+			if !res.Exited || !reflect.DeepEqual(res.Signals, want) || res.Method != method {
+				t.Fatalf("terminate = %+v, want an exit after %v via %s", res, want, method)
+			}
+			// poll(2) may wake up slightly early, hence the slack
+			if res.Elapsed < 150*time.Millisecond {
+				t.Fatalf("Elapsed = %v, SIGTERM step was cut short", res.Elapsed)
+			}
+			if sig := waitSignal(t, cmd); sig != syscall.SIGKILL {
+				t.Fatalf("child killed by %v, want SIGKILL", sig)
+			}
+		})
+	}
+}
+
+func TestTerminateAlreadyExited(t *testing.T) {
+	pm := NewProcessManager()
+	res, err := pm.Terminate(deadPID(t), TerminateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Exited || len(res.Signals) != 0 {
+		t.Fatalf("Terminate = %+v, want an exit without signals", res)
+	}
+}
+
+func TestTerminateInvalidPID(t *testing.T) {
+	pm := NewProcessManager()
+	for _, pid := range []int{0, -1} {
+		if _, err := pm.Terminate(pid, TerminateOptions{}); err == nil {
+			t.Errorf("Terminate(%d) succeeded", pid)
+		}
+	}
+}
+
+func TestTerminateStillRunning(t *testing.T) {
+	pm := NewProcessManager()
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		for _, method := range []string{MethodPidfd, MethodWait, MethodSignal0} {
+			t.Run(method+"/"+timeout.String(), func(t *testing.T) {
+				cmd := startChild(t, stubborn)
+				w, ok := watchers(t, pm, cmd.Process.Pid)[method]
+				if !ok {
+					t.Skipf("%s is not available", method)
+				}
+
+				start := time.Now()
+				res, err := terminate(w, cmd.Process.Pid, []Step{{Signal: syscall.SIGTERM, Timeout: timeout}})
+				if elapsed := time.Since(start); elapsed > time.Second {
+					t.Fatalf("a %v step took %v", timeout, elapsed)
+				}
+				if !errors.Is(err, ErrStillRunning) {
+					t.Fatalf("terminate = %v, want ErrStillRunning", err)
+				}
+				if res.Exited || !reflect.DeepEqual(res.Signals, []syscall.Signal{syscall.SIGTERM}) {
+					t.Fatalf("terminate = %+v, want SIGTERM sent and no exit", res)
+				}
+			})
+		}
+	}
+}
+
+func TestChildWatcherLeavesZombie(t *testing.T) {
+	pm := NewProcessManager()
+	cmd := startChild(t, sleeper)
+	pid := cmd.Process.Pid
+	w := &childWatcher{pollWatcher: &pollWatcher{pm: pm, pid: pid, interval: 10 * time.Millisecond}}
+
+	if gone, err := w.wait(0); err != nil || gone {
+		t.Fatalf("wait on a running child = %v, %v", gone, err)
+	}
+	if err := w.signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	if gone, err := w.wait(5 * time.Second); err != nil || !gone {
+		t.Fatalf("wait after SIGTERM = %v, %v", gone, err)
+	}
+	if !pm.isZombie(pid) {
+		t.Fatal("child was reaped by the watcher")
+	}
+	if sig := waitSignal(t, cmd); sig != syscall.SIGTERM {
+		t.Fatalf("child killed by %v, want SIGTERM", sig)
+	}
+}
+
+func TestPollWatcherSeesZombieAsExited(t *testing.T) {
+	pm := NewProcessManager()
+	cmd := startChild(t, sleeper)
+	pid := cmd.Process.Pid
+	w := &pollWatcher{pm: pm, pid: pid, interval: 10 * time.Millisecond}
+
+	if err := w.signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	// The zombie still answers signal 0, so only /proc shows the exit
+	if gone, err := w.wait(5 * time.Second); err != nil || !gone {
+		t.Fatalf("wait after SIGTERM = %v, %v", gone, err)
+	}
+	if err := w.signal(0); err != nil {
+		t.Fatalf("signal 0 to the zombie = %v, want nil", err)
+	}
+	if sig := waitSignal(t, cmd); sig != syscall.SIGTERM {
+		t.Fatalf("child killed by %v, want SIGTERM", sig)
+	}
+
+	// Once reaped the process is gone for signal 0 as well
+	if gone, err := w.wait(0); err != nil || !gone {
+		t.Fatalf("wait on a reaped child = %v, %v", gone, err)
+	}
+}
+
+func TestPollWatcherTreatsEPERMAsRunning(t *testing.T) {
+	if syscall.Getuid() == 0 {
+		t.Skip("root may signal every process")
+	}
+	w := &pollWatcher{pm: NewProcessManager(), pid: 1, interval: 10 * time.Millisecond}
+	if gone, err := w.wait(0); err != nil || gone {
+		t.Fatalf("wait on init = %v, %v; want running", gone, err)
+	}
+}
@@ -0,0 +1,88 @@
+//go:build linux
+
+package process
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// newExitWatcher prefers a pidfd, then waitid for children of the current
+// process, and falls back to signal 0.
+func newExitWatcher(pm *ProcessManager, pid int, interval time.Duration) exitWatcher {
+	base := &pollWatcher{pm: pm, pid: pid, interval: interval}
+	if fd, err := unix.PidfdOpen(pid, 0); err == nil {
+		return &pidfdWatcher{fd: fd}
+	}
+	if ppid, err := pm.parentPID(pid); err == nil && ppid == os.Getpid() {
+		return &childWatcher{pollWatcher: base}
+	}
+	return base
+}
+
+// pidfdWatcher signals and waits through a pidfd, so a recycled PID can
+// never be hit by mistake.
+type pidfdWatcher struct {
+	fd int
+}
+
+func (w *pidfdWatcher) signal(sig syscall.Signal) error {
+	return unix.PidfdSendSignal(w.fd, sig, nil, 0)
+}
+
+func (w *pidfdWatcher) wait(timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		// A negative timeout would make poll block forever, so a retry
+		// past the deadline polls once without waiting
+		ms := time.Until(deadline).Milliseconds()
+		if ms < 0 {
+			ms = 0
+		}
+		fds := []unix.PollFd{{Fd: int32(w.fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(ms))
+		if errors.Is(err, unix.EINTR) {
+			if ms == 0 {
+				return false, nil
+			}
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		return n > 0, nil
+	}
+}
+
+func (w *pidfdWatcher) method() string {
+	return MethodPidfd
+}
+
+func (w *pidfdWatcher) close() {
+	_ = unix.Close(w.fd)
+}
+
+// childWatcher waits for a child with WNOWAIT, leaving it to be reaped by
+// whoever started it.
+type childWatcher struct {
+	*pollWatcher
+}
+
+func (w *childWatcher) wait(timeout time.Duration) (bool, error) {
+	return poll(timeout, w.interval, func() (bool, error) {
+		var info unix.Siginfo
+		err := unix.Waitid(unix.P_PID, w.pid, &info, unix.WEXITED|unix.WNOHANG|unix.WNOWAIT, nil)
+		if errors.Is(err, unix.ECHILD) {
+			return true, nil
+		}
+		return info.Signo != 0, err
+	})
+}
+
+func (w *childWatcher) method() string {
+	return MethodWait
+}
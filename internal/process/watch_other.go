@@ -0,0 +1,12 @@
+//go:build !linux
+
+package process
+
+import (
+	"time"
+)
+
+// newExitWatcher polls with signal 0 where pidfd and /proc are not available.
+func newExitWatcher(pm *ProcessManager, pid int, interval time.Duration) exitWatcher {
+	return &pollWatcher{pm: pm, pid: pid, interval: interval}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package restart
+
+import (
+	"syscall"
+)
+
+// detachedAttr returns no attributes where sessions are not available.
+func detachedAttr() *syscall.SysProcAttr {
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build unix
+
+package restart
+
+import (
+	"syscall"
+)
+
+// detachedAttr starts the new process in its own session so that it outlives
+// the current one.
+func detachedAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
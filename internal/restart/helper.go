@@ -0,0 +1,176 @@
+package restart
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/process"
+)
+
+// HelperCommand is the hidden first argument that starts a binary in helper
+// mode instead of running the application.
+const HelperCommand = "__selfrestart-helper"
+
+// helperPollInterval is how often the helper checks whether the old process
+// is gone.
+const helperPollInterval = 500 * time.Millisecond
+
+// HelperArgs returns the arguments, after the program name, that start the
+// helper for spec:
+//
+//	__selfrestart-helper --wait-pid N --exec path [flags] -- args...
+func HelperArgs(spec Spec) []string {
+	args := []string{HelperCommand,
+		"--wait-pid", strconv.Itoa(spec.PID),
+		"--exec", spec.BinPath,
+	}
+	if spec.Dir != "" {
+		args = append(args, "--dir", spec.Dir)
+	}
+	if spec.LogPath != "" {
+		args = append(args, "--log", spec.LogPath)
+	}
+	if len(spec.Files) > 0 {
+		args = append(args, "--fds", strconv.Itoa(len(spec.Files)))
+	}
+	if rb := spec.Rollback; rb != nil {
+		args = append(args,
+			"--grace", rb.Grace.String(),
+			"--backup", rb.BackupPath,
+			"--state", rb.StatePath,
+		)
+		for _, arg := range rb.HealthCommand {
+			args = append(args, "--health", arg)
+		}
+	}
+	return append(append(args, "--"), spec.Args...)
+}
+
+// stringList collects the values of a repeated flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// ParseHelperArgs rebuilds the Spec encoded by HelperArgs, without the
+// leading HelperCommand. Inherited descriptors become spec.Files again.
+func ParseHelperArgs(args []string) (Spec, error) {
+	var spec Spec
+	var fds int
+	var rb Rollback
+	var health stringList
+
+	fs := flag.NewFlagSet(HelperCommand, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.IntVar(&spec.PID, "wait-pid", 0, "")
+	fs.StringVar(&spec.BinPath, "exec", "", "")
+	fs.StringVar(&spec.Dir, "dir", "", "")
+	fs.StringVar(&spec.LogPath, "log", "", "")
+	fs.IntVar(&fds, "fds", 0, "")
+	fs.DurationVar(&rb.Grace, "grace", 0, "")
+	fs.StringVar(&rb.BackupPath, "backup", "", "")
+	fs.StringVar(&rb.StatePath, "state", "", "")
+	fs.Var(&health, "health", "")
+	if err := fs.Parse(args); err != nil {
+		return spec, fmt.Errorf("invalid helper arguments: %v", err)
+	}
+	if spec.PID <= 0 || spec.BinPath == "" {
+		return spec, errors.New("invalid helper arguments: --wait-pid and --exec are required")
+	}
+	spec.Args = fs.Args()
+	for i := 0; i < fds; i++ {
+		fd := 3 + i
+		spec.Files = append(spec.Files, os.NewFile(uintptr(fd), "fd"+strconv.Itoa(fd)))
+	}
+	if rb.StatePath != "" {
+		rb.HealthCommand = health
+		spec.Rollback = &rb
+	}
+	return spec, nil
+}
+
+// RunHelper is the body of helper mode. It waits for spec.PID to exit,
+// starts spec.BinPath and, when a rollback is configured, watches the new
+// process until it is committed or rolled back. Progress goes to the helper
+// log.
+func (r *Restarter) RunHelper(args []string) error {
+	spec, err := ParseHelperArgs(args)
+	if err != nil {
+		return err
+	}
+	h := &helper{spec: spec, log: io.Discard}
+	if spec.LogPath != "" {
+		if f, err := os.OpenFile(spec.LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); err == nil {
+			defer func() { _ = f.Close() }()
+			h.log = f
+		}
+	}
+
+	h.logf("[helper] Waiting for process %d to finish...", spec.PID)
+	manager := process.NewProcessManager()
+	for {
+		running, err := manager.IsProcessRunning(spec.PID)
+		if err != nil {
+			return err
+		}
+		if !running {
+			break
+		}
+		time.Sleep(helperPollInterval)
+	}
+
+	h.logf("[helper] Old process finished. Trying to restart...")
+	cmd, err := h.start()
+	if err != nil {
+		h.logf("[helper] %v", err)
+		return err
+	}
+	if spec.Rollback == nil {
+		return cmd.Process.Release()
+	}
+	return h.watch(cmd)
+}
+
+// helper holds the state of one helper run.
+type helper struct {
+	spec Spec
+	log  io.Writer
+}
+
+func (h *helper) logf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(h.log, format+"\n", args...)
+}
+
+// start launches spec.BinPath with the arguments, environment, working
+// directory and descriptors inherited by the helper.
+func (h *helper) start() (*exec.Cmd, error) {
+	info, err := os.Stat(h.spec.BinPath)
+	if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+		return nil, fmt.Errorf("new binary %s not found or not executable", h.spec.BinPath)
+	}
+	h.logf("[helper] Executing new binary: %s", h.spec.BinPath)
+
+	cmd := exec.Command(h.spec.BinPath, h.spec.Args...)
+	cmd.Dir = h.spec.Dir
+	cmd.ExtraFiles = h.spec.Files
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start %s: %v", h.spec.BinPath, err)
+	}
+	return cmd, nil
+}
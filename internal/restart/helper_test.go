@@ -0,0 +1,63 @@
+package restart
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHelperArgsRoundTrip(t *testing.T) {
+	specs := map[string]Spec{
+		"minimal": {PID: 42, BinPath: "/usr/bin/app"},
+		"awkward arguments": {
+			PID:     42,
+			BinPath: "/opt/my app/bin/app",
+			Args:    []string{"--name", "with space", "", "--", "-x", "KEY=a=b", "--exec", "/bin/false"},
+			Dir:     "/srv/my app",
+			LogPath: "/var/log/app log/selfrestart.log",
+		},
+		"rollback": {
+			PID:     7,
+			BinPath: "/usr/bin/app",
+			Args:    []string{"serve"},
+			Rollback: &Rollback{
+				Grace:         1500 * time.Millisecond,
+				BackupPath:    "/usr/bin/app.selfrestart-backup",
+				StatePath:     "/tmp/state dir/app.json",
+				HealthCommand: []string{"curl", "-fsS", "", "http://localhost/?a=b c"},
+			},
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			args := HelperArgs(spec)
+			if args[0] != HelperCommand {
+				t.Fatalf("HelperArgs starts with %q", args[0])
+			}
+			got, err := ParseHelperArgs(args[1:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Args) == 0 {
+				got.Args = nil
+			}
+			if !reflect.DeepEqual(got, spec) {
+				t.Fatalf("ParseHelperArgs(HelperArgs(spec)) =\n%#v\nwant\n%#v", got, spec)
+			}
+		})
+	}
+}
+
+func TestParseHelperArgsInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"--exec", "/usr/bin/app"},
+		{"--wait-pid", "0", "--exec", "/usr/bin/app"},
+		{"--wait-pid", "1"},
+		{"--wait-pid", "1", "--exec", "/usr/bin/app", "--unknown"},
+	} {
+		if _, err := ParseHelperArgs(args); err == nil {
+			t.Errorf("ParseHelperArgs(%q) succeeded", args)
+		}
+	}
+}
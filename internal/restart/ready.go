@@ -0,0 +1,137 @@
+package restart
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvReadyFD names the descriptor a restarted process writes its readiness to.
+const EnvReadyFD = "SELFRESTART_READY_FD"
+
+// readyMessage is written by the child once it is initialized.
+const readyMessage = "READY=1"
+
+var (
+	// ErrNotReady is returned when the new process did not report readiness
+	// before the timeout.
+	ErrNotReady = errors.New("new process did not report readiness in time")
+	// ErrChildExited is returned when the new process exited before it
+	// reported readiness.
+	ErrChildExited = errors.New("new process exited before reporting readiness")
+)
+
+// ReadinessError reports a restart whose new process never became ready.
+type ReadinessError struct {
+	PID int
+	Err error
+}
+
+func (e *ReadinessError) Error() string {
+	return fmt.Sprintf("process %d: %v", e.PID, e.Err)
+}
+
+func (e *ReadinessError) Unwrap() error {
+	return e.Err
+}
+
+// StartAndWaitReady starts spec.BinPath right away, detached from the
+// current session, and waits until it calls NotifyReady or timeout expires.
+// The new process inherits spec.Files from descriptor 3 and a pipe for the
+// handshake after them. On failure the new process is killed and a
+// *ReadinessError returned; on success its PID is returned.
+func (r *Restarter) StartAndWaitReady(spec Spec, timeout time.Duration) (int, error) {
+	rd, wr, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("could not create readiness pipe: %v", err)
+	}
+	defer func() { _ = rd.Close() }()
+
+	env := spec.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	readyFD := 3 + len(spec.Files)
+	env = append(withoutEnv(env, EnvReadyFD), EnvReadyFD+"="+strconv.Itoa(readyFD))
+
+	cmd := exec.Command(spec.BinPath, spec.Args...)
+	cmd.Env = env
+	cmd.ExtraFiles = append(append([]*os.File(nil), spec.Files...), wr)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = detachedAttr()
+	if err := cmd.Start(); err != nil {
+		_ = wr.Close()
+		return 0, fmt.Errorf("could not start %s: %v", spec.BinPath, err)
+	}
+	_ = wr.Close()
+	pid := cmd.Process.Pid
+
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	ready := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(rd)
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == readyMessage {
+				close(ready)
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-ready:
+		return pid, nil
+	case <-exited:
+		return 0, &ReadinessError{PID: pid, Err: ErrChildExited}
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		<-exited
+		return 0, &ReadinessError{PID: pid, Err: ErrNotReady}
+	}
+}
+
+// NotifyReady tells the process that restarted us that we are ready. It is a
+// no-op when the process was not started with a readiness handshake, and only
+// reports once.
+func NotifyReady() error {
+	value := os.Getenv(EnvReadyFD)
+	if value == "" {
+		return nil
+	}
+	_ = os.Unsetenv(EnvReadyFD)
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < 3 {
+		return fmt.Errorf("invalid %s: %q", EnvReadyFD, value)
+	}
+	f := os.NewFile(uintptr(fd), "selfrestart-ready")
+	if f == nil {
+		return fmt.Errorf("invalid readiness descriptor %d", fd)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.WriteString(readyMessage + "\n"); err != nil {
+		return fmt.Errorf("could not report readiness: %v", err)
+	}
+	return nil
+}
+
+// withoutEnv returns env without the variable key.
+func withoutEnv(env []string, key string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, key+"=") {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
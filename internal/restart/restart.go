@@ -5,58 +5,114 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"syscall"
 )
 
+// RestartMode selects the strategy used to bring up the new process.
+type RestartMode string
+
+const (
+	// RestartModeHelper starts a detached copy of the binary in helper mode
+	// that waits for the current process to exit and then launches it again.
+	RestartModeHelper RestartMode = "helper"
+	// RestartModeExec replaces the current process image in place through
+	// syscall.Exec, keeping the PID, cgroup and supervisor relationship.
+	RestartModeExec RestartMode = "exec"
+)
+
+// Spec describes the process brought up by a restart.
+type Spec struct {
+	// PID is the process the helper waits for before starting the binary.
+	PID int
+	// BinPath is the executable to start.
+	BinPath string
+	// Args are passed to the new process after the program name.
+	Args []string
+	// Env is the environment of the new process; nil inherits the current one.
+	Env []string
+	// Files are inherited by the new process starting at descriptor 3.
+	Files []*os.File
+	// Rollback, when set, makes the helper watch the new process and restore
+	// the previous binary if it does not survive. Helper mode only.
+	Rollback *Rollback
+	// Dir is the working directory of the new process; empty inherits the
+	// current one.
+	Dir string
+	// LogPath is the helper log; empty means DefaultLogPath().
+	LogPath string
+}
+
+// HelperLogName is the file name of the helper log.
+const HelperLogName = "selfrestart.log"
+
 type Restarter struct{}
 
 func NewRestarter() *Restarter {
 	return &Restarter{}
 }
 
-func (r *Restarter) CreateAndExecRestartScript(oldPID int, binPath string) error {
-	script := fmt.Sprintf(`#!/bin/sh
-LOG="/tmp/selfrestart.log"
-echo "[trap] Preparing restart..." >> $LOG
-trap '
-  echo "[trap] Old process finished. Trying to restart..." >> $LOG
-  if [ -x "%s" ]; then
-    echo "[trap] Executing new binary: %s" >> $LOG
-    "%s" &
-  else
-    echo "[trap] New binary not found or not executable." >> $LOG
-  fi
-' EXIT
-
-echo "[info] Waiting for process %d to finish..." >> $LOG
-while kill -0 %d 2>/dev/null; do
-  sleep 0.5
-done
-
-exit
-`, binPath, binPath, binPath, oldPID, oldPID)
-
-	tmpPath := filepath.Join(os.TempDir(), "restart_helper.sh")
-	if err := os.WriteFile(tmpPath, []byte(script), 0755); err != nil {
-		return fmt.Errorf("could not write restart script: %v", err)
-	}
-
-	cmd := exec.Command("sh", tmpPath)
+// StartHelper starts a detached copy of the running binary in helper mode
+// (see HelperCommand). The helper waits for spec.PID to exit and then
+// launches spec.BinPath with spec.Args in spec.Dir. The helper and the new
+// process share spec.Env and spec.Files; no shell is involved.
+func (r *Restarter) StartHelper(spec Spec) error {
+	if spec.LogPath == "" {
+		spec.LogPath = DefaultLogPath()
+	}
+	if err := os.MkdirAll(filepath.Dir(spec.LogPath), 0700); err != nil {
+		return fmt.Errorf("could not create log directory: %v", err)
+	}
+
+	// The running image is used even if the file on disk was replaced, so a
+	// broken update cannot prevent its own rollback
+	cmd := exec.Command(runningExecutable(spec.BinPath), HelperArgs(spec)...)
+	// Shown in ps as the program rather than as /proc/self/exe
+	cmd.Args[0] = spec.BinPath
+	cmd.Env = spec.Env
+	cmd.Dir = spec.Dir
+	cmd.ExtraFiles = spec.Files
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = detachedAttr()
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("could not start restart script: %v", err)
+		return fmt.Errorf("could not start restart helper: %v", err)
 	}
 
-	prc := cmd.Process
-	if err := prc.Release(); err != nil {
+	if err := cmd.Process.Release(); err != nil {
 		return fmt.Errorf("could not detach restart process: %v", err)
 	}
+	return nil
+}
 
-	if len(os.Args) > 1 && os.Args[1] == "--wait" {
-		if err := cmd.Wait(); err != nil {
-			return cmd.Start()
-		}
+// DefaultLogPath returns the helper log used when none is configured, in
+// the per-user cache directory rather than a shared temporary directory.
+func DefaultLogPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
 	}
+	return filepath.Join(dir, "selfrestart", HelperLogName)
+}
 
+// ExecRestart replaces the current process with spec.BinPath, passing
+// spec.Args after the program name and spec.Env as the new environment. A nil
+// env reuses the current one. Descriptors meant to survive must already be
+// free of close-on-exec. On success it never returns.
+func (r *Restarter) ExecRestart(spec Spec) error {
+	binPath, args, env := spec.BinPath, spec.Args, spec.Env
+	if _, err := os.Stat(binPath); err != nil {
+		return fmt.Errorf("could not stat binary %s: %v", binPath, err)
+	}
+	if env == nil {
+		env = os.Environ()
+	}
+	argv0 := binPath
+	if len(os.Args) > 0 {
+		argv0 = os.Args[0]
+	}
+	argv := append([]string{argv0}, args...)
+	if err := syscall.Exec(binPath, argv, env); err != nil {
+		return fmt.Errorf("could not exec %s: %v", binPath, err)
+	}
 	return nil
 }
@@ -0,0 +1,233 @@
+package restart
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Outcome values recorded after a restart with rollback.
+const (
+	OutcomeCommitted  = "committed"
+	OutcomeRolledBack = "rolled_back"
+	OutcomeFailed     = "failed"
+)
+
+// Rollback configures the recovery of the previous binary when the new
+// process does not survive its grace period.
+type Rollback struct {
+	// Grace is how long the new process must stay alive to be committed.
+	Grace time.Duration
+	// BackupPath holds a copy of the binary that was running before the restart.
+	BackupPath string
+	// StatePath receives the outcome of the restart.
+	StatePath string
+	// HealthCommand, when set, runs at the end of the grace period and must
+	// exit 0 for the new process to be considered healthy.
+	HealthCommand []string
+}
+
+// Outcome is the result of a restart with rollback, as recorded by the helper.
+type Outcome struct {
+	Binary string
+	Result string
+	OldPID int
+	NewPID int
+	Reason string
+	Time   time.Time
+}
+
+// BackupPath returns where the previous version of binPath is kept.
+func BackupPath(binPath string) string {
+	return binPath + ".selfrestart-backup"
+}
+
+// StatePath returns the file recording the last restart outcome of binPath.
+func StatePath(binPath string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(filepath.Clean(binPath))
+	return filepath.Join(dir, "selfrestart", strings.TrimLeft(name, "_")+".state")
+}
+
+// runningExecutable returns a path to the image of the current process. On
+// Linux /proc/self/exe still points to it after the file on disk has been
+// replaced; elsewhere binPath is the best guess.
+func runningExecutable(binPath string) string {
+	if _, err := os.Stat("/proc/self/exe"); err == nil {
+		return "/proc/self/exe"
+	}
+	return binPath
+}
+
+// Backup copies the binary of the running process to backupPath, replacing
+// any previous backup atomically.
+func (r *Restarter) Backup(binPath, backupPath string) error {
+	src, err := os.Open(runningExecutable(binPath))
+	if err != nil {
+		return fmt.Errorf("could not open running binary: %v", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	tmp, err := os.CreateTemp(filepath.Dir(backupPath), filepath.Base(backupPath)+".*")
+	if err != nil {
+		return fmt.Errorf("could not create backup file: %v", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("could not copy running binary: %v", err)
+	}
+	if err := tmp.Chmod(0755); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("could not set backup permissions: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write backup file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), backupPath); err != nil {
+		return fmt.Errorf("could not install backup file: %v", err)
+	}
+	return nil
+}
+
+// LoadOutcome reads the outcome recorded at statePath.
+func LoadOutcome(statePath string) (*Outcome, error) {
+	f, err := os.Open(statePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	out := &Outcome{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "binary":
+			out.Binary = value
+		case "outcome":
+			out.Result = value
+		case "old_pid":
+			out.OldPID, _ = strconv.Atoi(value)
+		case "new_pid":
+			out.NewPID, _ = strconv.Atoi(value)
+		case "reason":
+			out.Reason = value
+		case "time":
+			out.Time, _ = time.Parse(time.RFC3339, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read state file %s: %v", statePath, err)
+	}
+	return out, nil
+}
+
+// SaveOutcome records out at statePath in the format read by LoadOutcome,
+// replacing the previous outcome atomically.
+func SaveOutcome(statePath string, out Outcome) error {
+	if err := os.MkdirAll(filepath.Dir(statePath), 0700); err != nil {
+		return fmt.Errorf("could not create state directory: %v", err)
+	}
+	content := fmt.Sprintf("binary=%s\noutcome=%s\nold_pid=%d\nnew_pid=%d\nreason=%s\ntime=%s\n",
+		out.Binary, out.Result, out.OldPID, out.NewPID, out.Reason, out.Time.UTC().Format(time.RFC3339))
+	tmp := statePath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0600); err != nil {
+		return fmt.Errorf("could not write state file: %v", err)
+	}
+	if err := os.Rename(tmp, statePath); err != nil {
+		return fmt.Errorf("could not install state file: %v", err)
+	}
+	return nil
+}
+
+// watch keeps an eye on the process started by the helper for the grace
+// period, restores the backup when it dies or is unhealthy, and records the
+// outcome.
+func (h *helper) watch(cmd *exec.Cmd) error {
+	rb := h.spec.Rollback
+	grace := rb.Grace
+	if grace < time.Second {
+		grace = time.Second
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	reason := ""
+	select {
+	case <-exited:
+		reason = "new process exited during the grace period"
+	case <-time.After(grace):
+		if len(rb.HealthCommand) > 0 {
+			health := exec.Command(rb.HealthCommand[0], rb.HealthCommand[1:]...)
+			health.Stdout = h.log
+			health.Stderr = h.log
+			if err := health.Run(); err != nil {
+				reason = "health check failed"
+				_ = cmd.Process.Kill()
+			}
+		}
+	}
+
+	out := Outcome{Binary: h.spec.BinPath, OldPID: h.spec.PID, Reason: reason, Time: time.Now()}
+	switch {
+	case reason == "":
+		h.logf("[rollback] New process %d committed.", cmd.Process.Pid)
+		_ = os.Remove(rb.BackupPath)
+		out.Result, out.NewPID = OutcomeCommitted, cmd.Process.Pid
+	case h.restore() == nil:
+		h.logf("[rollback] %s; restored previous binary.", reason)
+		out.Result = OutcomeRolledBack
+		if prev, err := h.start(); err != nil {
+			h.logf("[rollback] %v", err)
+		} else {
+			out.NewPID = prev.Process.Pid
+			_ = prev.Process.Release()
+		}
+	default:
+		h.logf("[rollback] %s; no backup to restore.", reason)
+		out.Result = OutcomeFailed
+	}
+	return SaveOutcome(rb.StatePath, out)
+}
+
+// restore puts the backup back in place of the binary atomically.
+func (h *helper) restore() error {
+	src, err := os.Open(h.spec.Rollback.BackupPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	tmp := h.spec.BinPath + ".rollback"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, h.spec.BinPath)
+}
@@ -0,0 +1,99 @@
+package restart
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ProbeFlag is passed to the candidate binary by the default validation. A
+// binary started with it should initialize, exit 0 and do nothing else.
+const ProbeFlag = "--selfrestart-probe"
+
+// DefaultProbeTimeout bounds a validation run when no timeout is configured.
+const DefaultProbeTimeout = 10 * time.Second
+
+// BinPlaceholder is replaced by the candidate binary path in Validation.Command.
+const BinPlaceholder = "{bin}"
+
+// probeWaitDelay bounds how long Validate waits for the probe output once the
+// probe has exited or been killed. Processes the probe left in the background
+// can hold the output pipe open for much longer.
+const probeWaitDelay = time.Second
+
+// maxProbeOutput caps the probe output kept in a ValidationError.
+const maxProbeOutput = 4096
+
+// Validation configures how a candidate binary is checked before the current
+// process is told to exit.
+type Validation struct {
+	// Command replaces the default "<bin> --selfrestart-probe" invocation.
+	// Elements equal to BinPlaceholder are replaced by the binary path.
+	Command []string
+	// Timeout bounds the probe run. Defaults to DefaultProbeTimeout.
+	Timeout time.Duration
+	// Env is the probe environment; nil inherits the current one.
+	Env []string
+}
+
+// ValidationError reports a candidate binary that failed validation.
+type ValidationError struct {
+	BinPath string
+	Command []string
+	Output  string
+	Err     error
+}
+
+func (e *ValidationError) Error() string {
+	msg := fmt.Sprintf("validation of %s failed: %v", e.BinPath, e.Err)
+	if e.Output != "" {
+		msg += ": " + e.Output
+	}
+	return msg
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate runs the candidate binary in probe mode and returns a
+// *ValidationError when it does not exit successfully within the timeout.
+func (r *Restarter) Validate(ctx context.Context, binPath string, v Validation) error {
+	argv := []string{binPath, ProbeFlag}
+	if len(v.Command) > 0 {
+		argv = make([]string, len(v.Command))
+		for i, arg := range v.Command {
+			argv[i] = strings.ReplaceAll(arg, BinPlaceholder, binPath)
+		}
+	}
+	timeout := v.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = v.Env
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	cmd.WaitDelay = probeWaitDelay
+	err := cmd.Run()
+	if err == nil || errors.Is(err, exec.ErrWaitDelay) {
+		// ErrWaitDelay means the probe itself exited successfully
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("probe timed out after %s", timeout)
+	}
+	output := strings.TrimSpace(out.String())
+	if len(output) > maxProbeOutput {
+		output = output[len(output)-maxProbeOutput:]
+	}
+	return &ValidationError{BinPath: binPath, Command: argv, Output: output, Err: err}
+}
@@ -0,0 +1,57 @@
+//go:build unix
+
+package restart
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	r := NewRestarter()
+	if err := r.Validate(context.Background(), "/bin/sh", Validation{Command: []string{"{bin}", "-c", "exit 0"}}); err != nil {
+		t.Fatalf("Validate = %v, want nil", err)
+	}
+
+	err := r.Validate(context.Background(), "/bin/sh", Validation{Command: []string{"{bin}", "-c", "echo broken; exit 3"}})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Output != "broken" || verr.Command[0] != "/bin/sh" {
+		t.Fatalf("Validate = %#v, want a ValidationError with the probe output", err)
+	}
+}
+
+func TestValidateDoesNotWaitForBackgroundedChildren(t *testing.T) {
+	r := NewRestarter()
+	tests := []struct {
+		name    string
+		script  string
+		timeout time.Duration
+		wantErr string
+	}{
+		{"probe succeeds", "sleep 30 & echo ok", time.Minute, ""},
+		{"probe fails", "sleep 30 & exit 1", time.Minute, "exit status 1"},
+		// The kill on timeout reaches sh but not the sleep it forked
+		{"probe times out", "sleep 30; exit 0", 100 * time.Millisecond, "timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := r.Validate(context.Background(), "/bin/sh", Validation{
+				Command: []string{"{bin}", "-c", tt.script},
+				Timeout: tt.timeout,
+			})
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Fatalf("Validate took %v", elapsed)
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Validate = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Validate = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/policy"
+	gl "github.com/rafa-mori/selfrestart/logger"
+)
+
+const DefaultStopTimeout = 10 * time.Second
+
+// startFailureCode is the exit code a child that could not be started counts
+// as for the restart policy, after the shell's "command not found".
+const startFailureCode = 127
+
+// ErrGaveUp is returned by Run when the restart policy refused to restart a
+// failed child.
+var ErrGaveUp = errors.New("restart policy gave up")
+
+// Config describes the supervised command and how exits are handled.
+type Config struct {
+	// Command is the program and its arguments.
+	Command []string
+	// Env is the child environment; nil inherits the current one.
+	Env []string
+	// Dir is the child working directory; empty uses the current one.
+	Dir string
+	// Policy decides whether and when the child is restarted after it
+	// exits. The zero value uses policy.Default.
+	Policy policy.Policy
+	// StopTimeout is how long a child gets to exit after SIGTERM before it
+	// is killed.
+	StopTimeout time.Duration
+}
+
+// Supervisor keeps a child process alive.
+type Supervisor struct {
+	cfg Config
+
+	mu        sync.Mutex
+	child     *os.Process
+	restartCh chan struct{}
+	// stopped records that the running child was asked to stop through
+	// Signal, so that its exit counts as deliberate.
+	stopped bool
+}
+
+func New(cfg Config) *Supervisor {
+	if cfg.Policy.Mode == "" {
+		cfg.Policy = policy.Default()
+	}
+	if cfg.StopTimeout <= 0 {
+		cfg.StopTimeout = DefaultStopTimeout
+	}
+	return &Supervisor{cfg: cfg, restartCh: make(chan struct{}, 1)}
+}
+
+// PID returns the PID of the running child, or 0 when there is none.
+func (s *Supervisor) PID() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.child == nil {
+		return 0
+	}
+	return s.child.Pid
+}
+
+// Signal forwards sig to the running child. A forwarded SIGINT or SIGTERM
+// marks the exit that follows as stopped for the restart policy.
+func (s *Supervisor) Signal(sig os.Signal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.child == nil {
+		return fmt.Errorf("no child running")
+	}
+	if err := s.child.Signal(sig); err != nil {
+		return err
+	}
+	if sig == syscall.SIGINT || sig == syscall.SIGTERM {
+		s.stopped = true
+	}
+	return nil
+}
+
+// Restart asks the supervisor to stop the child gracefully and start it
+// again right away, without counting it as a crash.
+func (s *Supervisor) Restart() {
+	select {
+	case s.restartCh <- struct{}{}:
+	default:
+	}
+}
+
+// Run starts the child and restarts it according to the policy, until ctx
+// is done or the policy declines a restart. It returns nil when the child
+// ended successfully and was not restarted. A child that cannot be started
+// counts as an exit with code 127. On return the child is no longer running.
+func (s *Supervisor) Run(ctx context.Context) error {
+	if len(s.cfg.Command) == 0 {
+		return fmt.Errorf("no command to supervise")
+	}
+	if err := s.cfg.Policy.Validate(); err != nil {
+		return err
+	}
+
+	tracker := policy.NewTracker(s.cfg.Policy)
+	name := s.cfg.Command[0]
+
+	for {
+		cmd, err := s.start()
+		if err != nil {
+			decision := tracker.Decide(policy.Exit{Code: startFailureCode, Time: time.Now()})
+			if !decision.Restart {
+				return fmt.Errorf("%w: %s (%v)", ErrGaveUp, decision.Reason, err)
+			}
+			gl.Log("warn", fmt.Sprintf("%v, retry %d in %s", err, decision.Attempt, decision.Delay))
+			if !s.wait(ctx, decision.Delay) {
+				return err
+			}
+			continue
+		}
+		startedAt := time.Now()
+		gl.Log("info", fmt.Sprintf("Started %s (PID %d)", name, cmd.Process.Pid))
+
+		exited := make(chan error, 1)
+		go func() { exited <- cmd.Wait() }()
+
+		var exitErr error
+		select {
+		case <-ctx.Done():
+			s.stop(cmd, exited)
+			return nil
+		case <-s.restartCh:
+			gl.Log("info", fmt.Sprintf("Restarting %s gracefully", name))
+			s.stop(cmd, exited)
+			tracker.Reset()
+			continue
+		case exitErr = <-exited:
+		}
+		stopped := s.clearChild()
+		if ctx.Err() != nil {
+			return exitErr
+		}
+
+		exit := exitOf(cmd.ProcessState, time.Since(startedAt))
+		exit.Stopped = stopped
+		decision := tracker.Decide(exit)
+		if !decision.Restart {
+			if !exit.Failed() {
+				gl.Log("info", fmt.Sprintf("%s exited cleanly: %s", name, decision.Reason))
+				return nil
+			}
+			return fmt.Errorf("%w: %s (%v)", ErrGaveUp, decision.Reason, exitErr)
+		}
+
+		gl.Log("warn", fmt.Sprintf("%s exited (%s), restart %d in %s", name, cmd.ProcessState, decision.Attempt, decision.Delay))
+		if !s.wait(ctx, decision.Delay) {
+			return exitErr
+		}
+	}
+}
+
+// wait sleeps for the restart delay, cut short by Restart. It reports false
+// when ctx is done first.
+func (s *Supervisor) wait(ctx context.Context, delay time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-s.restartCh:
+	case <-time.After(delay):
+	}
+	return true
+}
+
+// exitOf converts a process state into a policy exit. The signal alone does
+// not make the exit deliberate: the OOM killer and an outside kill -9 send
+// the same ones.
+func exitOf(state *os.ProcessState, uptime time.Duration) policy.Exit {
+	exit := policy.Exit{Code: state.ExitCode(), Uptime: uptime, Time: time.Now()}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		exit.Signaled = true
+	}
+	return exit
+}
+
+func (s *Supervisor) start() (*exec.Cmd, error) {
+	cmd := exec.Command(s.cfg.Command[0], s.cfg.Command[1:]...)
+	cmd.Env = s.cfg.Env
+	cmd.Dir = s.cfg.Dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start %s: %v", s.cfg.Command[0], err)
+	}
+	s.mu.Lock()
+	s.child = cmd.Process
+	s.stopped = false
+	s.mu.Unlock()
+	return cmd, nil
+}
+
+// clearChild forgets the exited child and reports whether it had been asked
+// to stop.
+func (s *Supervisor) clearChild() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.child = nil
+	return s.stopped
+}
+
+// stop sends SIGTERM to the child and kills it when it is still running
+// after StopTimeout.
+func (s *Supervisor) stop(cmd *exec.Cmd, exited <-chan error) {
+	defer func() { _ = s.clearChild() }()
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case <-exited:
+	case <-time.After(s.cfg.StopTimeout):
+		gl.Log("warn", fmt.Sprintf("%s did not stop within %s, killing it", s.cfg.Command[0], s.cfg.StopTimeout))
+		_ = cmd.Process.Kill()
+		<-exited
+	}
+}
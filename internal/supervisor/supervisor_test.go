@@ -0,0 +1,161 @@
+//go:build unix
+
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/policy"
+)
+
+func quickPolicy(mode policy.Mode, attempts int) policy.Policy {
+	return policy.Policy{
+		Mode:           mode,
+		MaxAttempts:    attempts,
+		InitialBackoff: policy.Duration(time.Millisecond),
+		MaxBackoff:     policy.Duration(time.Millisecond),
+	}
+}
+
+func TestRunCleanExit(t *testing.T) {
+	s := New(Config{Command: []string{"true"}, Policy: quickPolicy(policy.OnFailure, 3)})
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
+
+func TestRunGivesUpOnFailingChild(t *testing.T) {
+	s := New(Config{Command: []string{"false"}, Policy: quickPolicy(policy.OnFailure, 2)})
+	err := s.Run(context.Background())
+	if !errors.Is(err, ErrGaveUp) || !strings.Contains(err.Error(), "reached 2 restarts") {
+		t.Fatalf("Run = %v, want ErrGaveUp after 2 restarts", err)
+	}
+}
+
+func TestRunRetriesStartFailures(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	s := New(Config{Command: []string{missing}, Policy: quickPolicy(policy.OnFailure, 3)})
+	err := s.Run(context.Background())
+	if !errors.Is(err, ErrGaveUp) {
+		t.Fatalf("Run = %v, want ErrGaveUp", err)
+	}
+	if !strings.Contains(err.Error(), "reached 3 restarts") || !strings.Contains(err.Error(), "could not start") {
+		t.Fatalf("Run = %v, want the start error after 3 restarts", err)
+	}
+}
+
+func TestRunStartFailureFollowsPolicy(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	tests := []struct {
+		name   string
+		policy policy.Policy
+	}{
+		{"never", policy.Policy{Mode: policy.Never}},
+		{"exit code not listed", policy.Policy{Mode: policy.OnFailure, ExitCodes: []int{1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(Config{Command: []string{missing}, Policy: tt.policy}).Run(context.Background())
+			if !errors.Is(err, ErrGaveUp) || !strings.Contains(err.Error(), "could not start") {
+				t.Fatalf("Run = %v, want ErrGaveUp with the start error", err)
+			}
+		})
+	}
+}
+
+func TestRunRecoversWhenCommandAppears(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "svc")
+	p := policy.Policy{Mode: policy.OnFailure, InitialBackoff: policy.Duration(time.Hour), MaxBackoff: policy.Duration(time.Hour)}
+	s := New(Config{Command: []string{bin}, Policy: p})
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Run returned on a start failure: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Restart cuts the backoff short
+	s.Restart()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run = %v, want nil once the command started", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not retry after Restart")
+	}
+}
+
+func TestRunCanceledDuringStartBackoff(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	p := policy.Policy{Mode: policy.Always, InitialBackoff: policy.Duration(time.Hour), MaxBackoff: policy.Duration(time.Hour)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := New(Config{Command: []string{missing}, Policy: p}).Run(ctx)
+	if err == nil || errors.Is(err, ErrGaveUp) || !strings.Contains(err.Error(), "could not start") {
+		t.Fatalf("Run = %v, want the start error", err)
+	}
+}
+
+// countLines reports how many lines have been written to path.
+func countLines(path string) int {
+	data, _ := os.ReadFile(path)
+	return strings.Count(string(data), "\n")
+}
+
+func TestRunRestartsSelfKilledChildUnlessStopped(t *testing.T) {
+	count := filepath.Join(t.TempDir(), "count")
+	script := `echo run >> "$1"; kill -9 $$`
+	s := New(Config{Command: []string{"sh", "-c", script, "sh", count}, Policy: quickPolicy(policy.UnlessStopped, 2)})
+
+	err := s.Run(context.Background())
+	if !errors.Is(err, ErrGaveUp) {
+		t.Fatalf("Run = %v, want ErrGaveUp once the restarts ran out", err)
+	}
+	if n := countLines(count); n != 3 {
+		t.Fatalf("child ran %d times, want 3 (a SIGKILL is not a deliberate stop)", n)
+	}
+}
+
+func TestRunDoesNotRestartChildStoppedThroughSignal(t *testing.T) {
+	count := filepath.Join(t.TempDir(), "count")
+	script := `echo run >> "$1"; exec sleep 30`
+	s := New(Config{Command: []string{"sh", "-c", script, "sh", count}, Policy: quickPolicy(policy.UnlessStopped, 5)})
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for countLines(count) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("child did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := s.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrGaveUp) {
+			t.Fatalf("Run = %v, want ErrGaveUp for a stopped child", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run restarted a child stopped through Signal")
+	}
+	if n := countLines(count); n != 1 {
+		t.Fatalf("child ran %d times, want 1", n)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package systemd
+
+// monotonicUsec is unknown outside Linux, where systemd does not run.
+func monotonicUsec() int64 {
+	return 0
+}
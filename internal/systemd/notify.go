@@ -0,0 +1,118 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvNotifySocket is set by systemd for units with Type=notify.
+	EnvNotifySocket = "NOTIFY_SOCKET"
+	// EnvWatchdogUsec is the watchdog interval requested with WatchdogSec=.
+	EnvWatchdogUsec = "WATCHDOG_USEC"
+	// EnvWatchdogPID names the process the watchdog applies to.
+	EnvWatchdogPID = "WATCHDOG_PID"
+)
+
+// Notification states understood by systemd.
+const (
+	StateReady     = "READY=1"
+	StateReloading = "RELOADING=1"
+	StateStopping  = "STOPPING=1"
+	StateWatchdog  = "WATCHDOG=1"
+)
+
+// ErrNotifyDisabled is returned when there is no notify socket to talk to.
+var ErrNotifyDisabled = errors.New("systemd notify socket not available")
+
+// Notifier sends sd_notify messages to a notify socket.
+type Notifier struct {
+	addr string
+}
+
+// NewNotifier returns a notifier for the socket in NOTIFY_SOCKET. It is
+// disabled when the process does not run under a notify unit.
+func NewNotifier() *Notifier {
+	return NewNotifierAt(os.Getenv(EnvNotifySocket))
+}
+
+// NewNotifierAt returns a notifier for the datagram socket at addr. An addr
+// starting with "@" refers to the abstract namespace, as in NOTIFY_SOCKET.
+func NewNotifierAt(addr string) *Notifier {
+	return &Notifier{addr: addr}
+}
+
+// Enabled reports whether the notifier has a socket to send to.
+func (n *Notifier) Enabled() bool {
+	return n != nil && n.addr != ""
+}
+
+// Notify sends states, newline separated, in one datagram. It returns
+// ErrNotifyDisabled when the notifier has no socket.
+func (n *Notifier) Notify(states ...string) error {
+	if !n.Enabled() {
+		return ErrNotifyDisabled
+	}
+	addr := n.addr
+	if strings.HasPrefix(addr, "@") {
+		addr = "\x00" + addr[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("could not connect to notify socket %s: %v", n.addr, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return fmt.Errorf("could not notify systemd: %v", err)
+	}
+	return nil
+}
+
+// Reloading tells systemd a restart is in progress. MONOTONIC_USEC is
+// included where available, as Type=notify-reload units require it.
+func (n *Notifier) Reloading() error {
+	if usec := monotonicUsec(); usec > 0 {
+		return n.Notify(StateReloading, "MONOTONIC_USEC="+strconv.FormatInt(usec, 10))
+	}
+	return n.Notify(StateReloading)
+}
+
+// WatchdogInterval returns the watchdog interval requested for this process.
+// It reports false when WATCHDOG_USEC is unset, invalid, or meant for another
+// process according to WATCHDOG_PID.
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv(EnvWatchdogUsec), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if value := os.Getenv(EnvWatchdogPID); value != "" {
+		pid, err := strconv.Atoi(value)
+		if err != nil || pid != os.Getpid() {
+			return 0, false
+		}
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// RunWatchdog sends WATCHDOG=1 every interval/2 until ctx is done. Errors
+// are passed to onError, when set, and do not stop the loop.
+func (n *Notifier) RunWatchdog(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.Notify(StateWatchdog); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen opens a fake notify socket at addr and returns it with the address
+// to hand to NewNotifierAt.
+func listen(t *testing.T, addr string) *net.UnixConn {
+	t.Helper()
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen %s: %v", addr, err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// recv returns the next datagram received on conn.
+func recv(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	buf := make([]byte, 4096)
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read notify socket: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestNotifyDisabled(t *testing.T) {
+	n := NewNotifierAt("")
+	if n.Enabled() {
+		t.Fatal("notifier without socket reports enabled")
+	}
+	if err := n.Notify(StateReady); !errors.Is(err, ErrNotifyDisabled) {
+		t.Fatalf("Notify = %v, want ErrNotifyDisabled", err)
+	}
+	var nilNotifier *Notifier
+	if nilNotifier.Enabled() {
+		t.Fatal("nil notifier reports enabled")
+	}
+}
+
+func TestNotifyFromEnv(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "notify.sock")
+	conn := listen(t, addr)
+	t.Setenv(EnvNotifySocket, addr)
+
+	n := NewNotifier()
+	if !n.Enabled() {
+		t.Fatal("notifier from NOTIFY_SOCKET is disabled")
+	}
+	if err := n.Notify(StateReady); err != nil {
+		t.Fatal(err)
+	}
+	if got := recv(t, conn); got != "READY=1" {
+		t.Fatalf("got %q, want READY=1", got)
+	}
+}
+
+func TestNotifyStates(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "notify.sock")
+	conn := listen(t, addr)
+	n := NewNotifierAt(addr)
+
+	tests := []struct {
+		states []string
+		want   string
+	}{
+		{[]string{StateReady}, "READY=1"},
+		{[]string{"MAINPID=4242", StateReady}, "MAINPID=4242\nREADY=1"},
+		{[]string{StateStopping}, "STOPPING=1"},
+		{[]string{StateWatchdog}, "WATCHDOG=1"},
+		{[]string{"STATUS=draining connections"}, "STATUS=draining connections"},
+	}
+	for _, tt := range tests {
+		if err := n.Notify(tt.states...); err != nil {
+			t.Fatalf("Notify(%q): %v", tt.states, err)
+		}
+		if got := recv(t, conn); got != tt.want {
+			t.Errorf("Notify(%q) sent %q, want %q", tt.states, got, tt.want)
+		}
+	}
+}
+
+func TestNotifyAbstractSocket(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("abstract unix sockets are Linux only")
+	}
+	name := fmt.Sprintf("@selfrestart-test-%d-%d", os.Getpid(), time.Now().UnixNano())
+	conn := listen(t, name)
+
+	if err := NewNotifierAt(name).Notify(StateReady); err != nil {
+		t.Fatal(err)
+	}
+	if got := recv(t, conn); got != "READY=1" {
+		t.Fatalf("got %q, want READY=1", got)
+	}
+}
+
+func TestNotifyMissingSocket(t *testing.T) {
+	n := NewNotifierAt(filepath.Join(t.TempDir(), "missing.sock"))
+	if err := n.Notify(StateReady); err == nil {
+		t.Fatal("Notify to a missing socket succeeded")
+	}
+}
+
+func TestReloading(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "notify.sock")
+	conn := listen(t, addr)
+
+	before := monotonicUsec()
+	if err := NewNotifierAt(addr).Reloading(); err != nil {
+		t.Fatal(err)
+	}
+	after := monotonicUsec()
+	got := recv(t, conn)
+
+	if before == 0 {
+		if got != StateReloading {
+			t.Fatalf("got %q, want %q", got, StateReloading)
+		}
+		return
+	}
+	value, ok := strings.CutPrefix(got, StateReloading+"\nMONOTONIC_USEC=")
+	if !ok {
+		t.Fatalf("got %q, want RELOADING=1 followed by MONOTONIC_USEC", got)
+	}
+	usec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		t.Fatalf("MONOTONIC_USEC %q: %v", value, err)
+	}
+	if usec < before || usec > after {
+		t.Fatalf("MONOTONIC_USEC = %d, want between %d and %d", usec, before, after)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	self := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		name   string
+		usec   string
+		pid    string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"unset", "", "", 0, false},
+		{"invalid", "soon", "", 0, false},
+		{"zero", "0", "", 0, false},
+		{"no pid", "2000000", "", 2 * time.Second, true},
+		{"matching pid", "500000", self, 500 * time.Millisecond, true},
+		{"other pid", "2000000", strconv.Itoa(os.Getpid() + 1), 0, false},
+		{"invalid pid", "2000000", "main", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvWatchdogUsec, tt.usec)
+			t.Setenv(EnvWatchdogPID, tt.pid)
+			got, ok := WatchdogInterval()
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("WatchdogInterval() = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRunWatchdog(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "notify.sock")
+	conn := listen(t, addr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewNotifierAt(addr).RunWatchdog(ctx, 20*time.Millisecond, func(err error) {
+			t.Errorf("watchdog: %v", err)
+		})
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		if got := recv(t, conn); got != StateWatchdog {
+			t.Fatalf("keepalive %d = %q, want %q", i, got, StateWatchdog)
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunWatchdog did not stop after cancel")
+	}
+}
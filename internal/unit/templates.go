@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"text/template"
+)
+
+// systemdTemplate maps the restart policy onto Restart=, RestartSec= and the
+// start rate limit. on-failure limited to exit codes becomes on-abnormal plus
+// RestartForceExitStatus=, so that crashes still count. Jitter has no
+// systemd equivalent.
+var systemdTemplate = template.Must(template.New("systemd").Funcs(funcs).Parse(`[Unit]
+Description={{specifiers .Description}}
+After=network-online.target
+Wants=network-online.target
+{{- with .Policy}}
+{{- if gt .MaxAttempts 0}}
+StartLimitBurst={{.MaxAttempts}}
+StartLimitIntervalSec={{if gt .Window 0}}{{seconds .Window}}{{else}}infinity{{end}}
+{{- else}}
+StartLimitIntervalSec=0
+{{- end}}
+{{- end}}
+
+[Service]
+{{- if .Notify}}
+Type=notify
+NotifyAccess=main
+{{- else}}
+Type=simple
+{{- end}}
+ExecStart={{systemdArgs .}}
+{{- if .Notify}}
+ExecReload=/bin/kill -USR1 $MAINPID
+{{- end}}
+{{- with .Policy}}
+{{- if eq .Mode "never"}}
+Restart=no
+{{- else if eq .Mode "on-failure"}}
+{{- if .ExitCodes}}
+Restart=on-abnormal
+RestartForceExitStatus={{join .ExitCodes}}
+{{- else}}
+Restart=on-failure
+{{- end}}
+{{- else}}
+Restart=always
+{{- end}}
+{{- if ne .Mode "never"}}
+{{- if gt .InitialBackoff 0}}
+RestartSec={{seconds .InitialBackoff}}
+{{- end}}
+{{- if gt (steps .) 0}}
+RestartSteps={{steps .}}
+RestartMaxDelaySec={{seconds .MaxBackoff}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if gt .Watchdog 0}}
+WatchdogSec={{watchdog .Watchdog}}
+{{- end}}
+{{- if .User}}
+User={{.User}}
+{{- end}}
+{{- if .WorkingDir}}
+WorkingDirectory={{specifiers .WorkingDir}}
+{{- end}}
+{{- range env .Env}}
+Environment={{systemdValue .}}
+{{- end}}
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// openrcTemplate uses supervise-daemon to respawn the service unless the
+// policy is never. supervise-daemon respawns on any exit, so on-failure and
+// exit code filters cannot be expressed.
+var openrcTemplate = template.Must(template.New("openrc").Funcs(funcs).Parse(`#!/sbin/openrc-run
+
+description={{shellQuote .Description}}
+command={{shellQuote .Exec}}
+{{- if .Args}}
+command_args={{shellQuote (shellArgs .Args)}}
+{{- end}}
+{{- if .User}}
+command_user={{shellQuote .User}}
+{{- end}}
+{{- if .WorkingDir}}
+directory={{shellQuote .WorkingDir}}
+{{- end}}
+pidfile="/run/${RC_SVCNAME}.pid"
+{{- with .Policy}}
+{{- if eq .Mode "never"}}
+command_background=true
+{{- else}}
+supervisor=supervise-daemon
+{{- if gt .InitialBackoff 0}}
+respawn_delay={{wholeSeconds .InitialBackoff}}
+{{- end}}
+{{- if gt .MaxAttempts 0}}
+respawn_max={{.MaxAttempts}}
+{{- if gt .Window 0}}
+respawn_period={{wholeSeconds .Window}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- range env .Env}}
+export {{shellQuote .}}
+{{- end}}
+{{- if .Notify}}
+
+extra_started_commands="reload"
+
+reload() {
+	ebegin "Restarting ${RC_SVCNAME} in place"
+	{{- if eq .Policy.Mode "never"}}
+	start-stop-daemon --signal USR1 --pidfile "${pidfile}"
+	{{- else}}
+	supervise-daemon "${RC_SVCNAME}" --signal USR1
+	{{- end}}
+	eend $?
+}
+{{- end}}
+
+depend() {
+	need net
+}
+`))
+
+// launchdTemplate maps always and unless-stopped to KeepAlive, on-failure to
+// KeepAlive on unsuccessful exit and the initial backoff to ThrottleInterval.
+var launchdTemplate = template.Must(template.New("launchd").Funcs(funcs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{xml .Name}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{xml .Exec}}</string>
+		{{- range .Args}}
+		<string>{{xml .}}</string>
+		{{- end}}
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	{{- with .Policy}}
+	<key>KeepAlive</key>
+	{{- if eq .Mode "never"}}
+	<false/>
+	{{- else if eq .Mode "on-failure"}}
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	{{- else}}
+	<true/>
+	{{- end}}
+	{{- if gt .InitialBackoff 0}}
+	<key>ThrottleInterval</key>
+	<integer>{{wholeSeconds .InitialBackoff}}</integer>
+	{{- end}}
+	{{- end}}
+	{{- if .User}}
+	<key>UserName</key>
+	<string>{{xml .User}}</string>
+	{{- end}}
+	{{- if .WorkingDir}}
+	<key>WorkingDirectory</key>
+	<string>{{xml .WorkingDir}}</string>
+	{{- end}}
+	{{- if .Env}}
+	<key>EnvironmentVariables</key>
+	<dict>
+		{{- range $k, $v := .Env}}
+		<key>{{xml $k}}</key>
+		<string>{{xml $v}}</string>
+		{{- end}}
+	</dict>
+	{{- end}}
+</dict>
+</plist>
+`))
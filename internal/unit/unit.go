@@ -0,0 +1,265 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/policy"
+)
+
+// Format is a service manager the unit is rendered for.
+type Format string
+
+const (
+	Systemd Format = "systemd"
+	OpenRC  Format = "openrc"
+	Launchd Format = "launchd"
+)
+
+// Formats lists every supported format.
+var Formats = []Format{Systemd, OpenRC, Launchd}
+
+// DefaultFormat returns the service manager of the current platform.
+func DefaultFormat() Format {
+	if runtime.GOOS == "darwin" {
+		return Launchd
+	}
+	return Systemd
+}
+
+// Spec describes the service to render.
+type Spec struct {
+	// Name identifies the service and names the unit file.
+	Name        string
+	Description string
+	// Exec is the absolute path of the program; Args follow it.
+	Exec string
+	Args []string
+	// User runs the service as another user when set.
+	User string
+	// WorkingDir is the working directory of the service when set.
+	WorkingDir string
+	Env        map[string]string
+	// Policy drives the restart settings of the service manager.
+	Policy policy.Policy
+	// Notify marks a program that links selfrestart: it reports readiness
+	// with sd_notify and restarts itself on SIGUSR1.
+	Notify bool
+	// Watchdog enables the systemd watchdog with this interval.
+	Watchdog time.Duration
+}
+
+// Validate reports configuration errors.
+func (s Spec) Validate() error {
+	if err := validateName(s.Name); err != nil {
+		return err
+	}
+	if !filepath.IsAbs(s.Exec) {
+		return fmt.Errorf("executable must be an absolute path: %q", s.Exec)
+	}
+	if s.Watchdog > 0 && !s.Notify {
+		return errors.New("the watchdog requires a notify service")
+	}
+	return s.Policy.Validate()
+}
+
+// validateName refuses names that would place the unit outside its
+// directory.
+func validateName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/\\ ") || name == "." || name == ".." {
+		return fmt.Errorf("invalid service name %q", name)
+	}
+	return nil
+}
+
+// Path returns where the unit of the service called name is installed below
+// root.
+func Path(format Format, root, name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+	switch format {
+	case Systemd:
+		return filepath.Join(root, "etc", "systemd", "system", name+".service"), nil
+	case OpenRC:
+		return filepath.Join(root, "etc", "init.d", name), nil
+	case Launchd:
+		return filepath.Join(root, "Library", "LaunchDaemons", name+".plist"), nil
+	}
+	return "", fmt.Errorf("unknown service format %q", format)
+}
+
+// Render returns the unit of s in the given format.
+func Render(format Format, s Spec) ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if s.Description == "" {
+		s.Description = s.Name
+	}
+	var tmpl *template.Template
+	switch format {
+	case Systemd:
+		tmpl = systemdTemplate
+	case OpenRC:
+		tmpl = openrcTemplate
+	case Launchd:
+		tmpl = launchdTemplate
+	default:
+		return nil, fmt.Errorf("unknown service format %q", format)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, s); err != nil {
+		return nil, fmt.Errorf("could not render %s unit: %v", format, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Install renders s and writes it below root, returning the file written.
+func Install(format Format, root string, s Spec) (string, error) {
+	data, err := Render(format, s)
+	if err != nil {
+		return "", err
+	}
+	path, err := Path(format, root, s.Name)
+	if err != nil {
+		return "", err
+	}
+	mode := os.FileMode(0644)
+	if format == OpenRC {
+		mode = 0755
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("could not create %s: %v", filepath.Dir(path), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return "", fmt.Errorf("could not create unit file: %v", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("could not write unit file: %v", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("could not set unit file permissions: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("could not write unit file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("could not install unit file: %v", err)
+	}
+	return path, nil
+}
+
+// Uninstall removes the unit of the service called name below root,
+// returning the file removed.
+func Uninstall(format Format, root, name string) (string, error) {
+	path, err := Path(format, root, name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("could not remove unit file: %v", err)
+	}
+	return path, nil
+}
+
+// seconds formats d as a number of seconds, as all three formats accept.
+func seconds(d policy.Duration) string {
+	return strconv.FormatFloat(d.Duration().Seconds(), 'f', -1, 64)
+}
+
+// wholeSeconds rounds d up to whole seconds, for fields taking integers.
+func wholeSeconds(d policy.Duration) int64 {
+	return int64(math.Ceil(d.Duration().Seconds()))
+}
+
+// backoffSteps returns how many doublings take the initial backoff to the
+// maximum, for systemd's RestartSteps=.
+func backoffSteps(p policy.Policy) int {
+	initial, max := p.InitialBackoff.Duration(), p.MaxBackoff.Duration()
+	if initial <= 0 || max <= initial {
+		return 0
+	}
+	return int(math.Ceil(math.Log2(float64(max) / float64(initial))))
+}
+
+// sortedEnv returns env as sorted KEY=VALUE pairs so that output is stable.
+func sortedEnv(env map[string]string) []string {
+	pairs := make([]string, 0, len(env))
+	for k, v := range env {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// systemdQuote quotes s as one word of a systemd command line, where "$"
+// would otherwise expand a variable.
+func systemdQuote(s string) string {
+	return strings.ReplaceAll(systemdValue(s), "$", "$$")
+}
+
+// systemdValue quotes s as the value of a systemd setting such as
+// Environment=, escaping specifiers.
+func systemdValue(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// shellQuote quotes s as one literal word for sh, leaving plain words as is.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_./:=@%+,-") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// xmlEscape escapes s for use as XML character data.
+func xmlEscape(s string) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+	return r.Replace(s)
+}
+
+func quoteAll(quote func(string) string, words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = quote(w)
+	}
+	return strings.Join(quoted, " ")
+}
+
+var funcs = template.FuncMap{
+	"seconds":      seconds,
+	"wholeSeconds": wholeSeconds,
+	"steps":        backoffSteps,
+	"env":          sortedEnv,
+	"systemdQuote": systemdQuote,
+	"systemdValue": systemdValue,
+	"specifiers":   func(s string) string { return strings.ReplaceAll(s, "%", "%%") },
+	"shellQuote":   shellQuote,
+	"xml":          xmlEscape,
+	"systemdArgs":  func(s Spec) string { return quoteAll(systemdQuote, append([]string{s.Exec}, s.Args...)) },
+	"shellArgs":    func(args []string) string { return quoteAll(shellQuote, args) },
+	"join": func(codes []int) string {
+		words := make([]string, len(codes))
+		for i, c := range codes {
+			words[i] = strconv.Itoa(c)
+		}
+		return strings.Join(words, " ")
+	},
+	"watchdog": func(d time.Duration) string { return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) },
+}
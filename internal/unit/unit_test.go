@@ -0,0 +1,256 @@
+package unit
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/policy"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// specs are rendered in every format and compared with testdata/<name>.<format>.
+var specs = map[string]Spec{
+	"minimal": {
+		Name:   "mysvc",
+		Exec:   "/usr/local/bin/mysvc",
+		Policy: policy.Default(),
+	},
+	"full": {
+		Name:        "api",
+		Description: `API server & "friends" <prod>`,
+		Exec:        "/opt/api/bin/api server",
+		Args:        []string{"--port", "8080", "--greeting", "it's 100% $HOME"},
+		User:        "www-data",
+		WorkingDir:  "/srv/api",
+		Env:         map[string]string{"TZ": "UTC", "GREETING": `say "hi" 50%`},
+		Policy: policy.Policy{
+			Mode:           policy.OnFailure,
+			ExitCodes:      []int{1, 2},
+			MaxAttempts:    3,
+			Window:         policy.Duration(2 * time.Minute),
+			InitialBackoff: policy.Duration(500 * time.Millisecond),
+			MaxBackoff:     policy.Duration(8 * time.Second),
+		},
+		Notify:   true,
+		Watchdog: 30 * time.Second,
+	},
+	"always": {
+		Name:   "worker",
+		Exec:   "/usr/bin/worker",
+		Policy: policy.Policy{Mode: policy.Always},
+	},
+	"unless-stopped": {
+		Name:   "worker",
+		Exec:   "/usr/bin/worker",
+		Policy: policy.Policy{Mode: policy.UnlessStopped, InitialBackoff: policy.Duration(time.Second)},
+	},
+	"never": {
+		Name:   "oneshot",
+		Exec:   "/usr/bin/oneshot",
+		Policy: policy.Policy{Mode: policy.Never},
+	},
+}
+
+func TestRenderGolden(t *testing.T) {
+	for name, spec := range specs {
+		for _, format := range Formats {
+			t.Run(name+"/"+string(format), func(t *testing.T) {
+				got, err := Render(format, spec)
+				if err != nil {
+					t.Fatal(err)
+				}
+				golden := filepath.Join("testdata", name+"."+string(format))
+				if *update {
+					if err := os.WriteFile(golden, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run go test -update to create it)", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("Render differs from %s:\n--- got\n%s\n--- want\n%s", golden, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestRenderInvalid(t *testing.T) {
+	valid := specs["minimal"]
+	tests := []struct {
+		name   string
+		modify func(*Spec)
+	}{
+		{"empty name", func(s *Spec) { s.Name = "" }},
+		{"name with slash", func(s *Spec) { s.Name = "../evil" }},
+		{"name with space", func(s *Spec) { s.Name = "my svc" }},
+		{"relative exec", func(s *Spec) { s.Exec = "bin/mysvc" }},
+		{"watchdog without notify", func(s *Spec) { s.Watchdog = time.Second }},
+		{"bad policy", func(s *Spec) { s.Policy.Mode = "sometimes" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := valid
+			tt.modify(&spec)
+			if _, err := Render(Systemd, spec); err == nil {
+				t.Fatal("Render succeeded")
+			}
+		})
+	}
+	if _, err := Render(Format("upstart"), valid); err == nil {
+		t.Fatal("Render of an unknown format succeeded")
+	}
+}
+
+func TestInstallUninstall(t *testing.T) {
+	wantPaths := map[Format]string{
+		Systemd: "etc/systemd/system/mysvc.service",
+		OpenRC:  "etc/init.d/mysvc",
+		Launchd: "Library/LaunchDaemons/mysvc.plist",
+	}
+	wantModes := map[Format]os.FileMode{Systemd: 0o644, OpenRC: 0o755, Launchd: 0o644}
+	spec := specs["minimal"]
+
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			root := t.TempDir()
+			path, err := Install(format, root, spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(root, wantPaths[format]); path != want {
+				t.Fatalf("Install wrote %s, want %s", path, want)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != wantModes[format] {
+				t.Errorf("mode = %v, want %v", info.Mode().Perm(), wantModes[format])
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := Render(format, spec)
+			if !bytes.Equal(data, want) {
+				t.Errorf("installed unit differs from Render")
+			}
+
+			// Reinstalling replaces the file and leaves no temporary behind
+			if _, err := Install(format, root, spec); err != nil {
+				t.Fatal(err)
+			}
+			entries, err := os.ReadDir(filepath.Dir(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if strings.HasPrefix(e.Name(), ".") {
+					t.Errorf("leftover temporary file %s", e.Name())
+				}
+			}
+
+			removed, err := Uninstall(format, root, spec.Name)
+			if err != nil || removed != path {
+				t.Fatalf("Uninstall = %s, %v; want %s", removed, err, path)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("unit still present after Uninstall: %v", err)
+			}
+			if _, err := Uninstall(format, root, spec.Name); err == nil {
+				t.Fatal("Uninstall of a missing unit succeeded")
+			}
+		})
+	}
+}
+
+func TestInstallInvalidSpec(t *testing.T) {
+	root := t.TempDir()
+	spec := specs["minimal"]
+	spec.Exec = "mysvc"
+	if _, err := Install(Systemd, root, spec); err == nil {
+		t.Fatal("Install of an invalid spec succeeded")
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Install of an invalid spec wrote files: %v", entries)
+	}
+}
+
+func TestBackoffSteps(t *testing.T) {
+	tests := []struct {
+		initial, max time.Duration
+		want         int
+	}{
+		{0, time.Minute, 0},
+		{time.Second, time.Second, 0},
+		{time.Second, 2 * time.Second, 1},
+		{time.Second, 30 * time.Second, 5},
+		{500 * time.Millisecond, 8 * time.Second, 4},
+	}
+	for _, tt := range tests {
+		p := policy.Policy{InitialBackoff: policy.Duration(tt.initial), MaxBackoff: policy.Duration(tt.max)}
+		if got := backoffSteps(p); got != tt.want {
+			t.Errorf("backoffSteps(%v, %v) = %d, want %d", tt.initial, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	tests := []struct {
+		quote func(string) string
+		in    string
+		want  string
+	}{
+		{systemdQuote, `a b`, `"a b"`},
+		{systemdQuote, `$HOME 100% "x" \n`, `"$$HOME 100%% \"x\" \\n"`},
+		{systemdValue, "$HOME\nnext", `"$HOME\nnext"`},
+		{shellQuote, "plain-word_1.0", "plain-word_1.0"},
+		{shellQuote, "", "''"},
+		{shellQuote, "it's $HOME", `'it'\''s $HOME'`},
+		{xmlEscape, `<a & 'b' "c">`, "&lt;a &amp; &apos;b&apos; &quot;c&quot;&gt;"},
+	}
+	for _, tt := range tests {
+		if got := tt.quote(tt.in); got != tt.want {
+			t.Errorf("quote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUninstallRejectsTraversal(t *testing.T) {
+	root := t.TempDir()
+	victim := filepath.Join(root, "etc", "passwd")
+	if err := os.MkdirAll(filepath.Dir(victim), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(victim, []byte("root:x:0:0"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range Formats {
+		for _, name := range []string{"../passwd", "../../etc/passwd", "..", ".", "", `a\b`} {
+			if _, err := Uninstall(format, root, name); err == nil {
+				t.Errorf("Uninstall(%s, %q) succeeded", format, name)
+			}
+			if _, err := Path(format, root, name); err == nil {
+				t.Errorf("Path(%s, %q) succeeded", format, name)
+			}
+		}
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Fatalf("file outside the unit directory removed: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultStableFor is how long a replaced binary must stay untouched before
+// it is reported.
+const DefaultStableFor = 2 * time.Second
+
+// BinaryWatcher notices when an executable is replaced or rewritten.
+type BinaryWatcher struct {
+	path      string
+	stableFor time.Duration
+}
+
+func NewBinaryWatcher(path string, stableFor time.Duration) *BinaryWatcher {
+	if stableFor <= 0 {
+		stableFor = DefaultStableFor
+	}
+	return &BinaryWatcher{path: filepath.Clean(path), stableFor: stableFor}
+}
+
+// Path returns the watched executable path.
+func (w *BinaryWatcher) Path() string {
+	return w.path
+}
+
+// Run watches the directory holding the binary, so that replacements through
+// rename are seen too, and sends the path on changed every time the file
+// differs from the last reported version, has stopped changing for the
+// stability window and is executable. It returns when ctx is done.
+func (w *BinaryWatcher) Run(ctx context.Context, changed chan<- string) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("could not create file watcher: %v", err)
+	}
+	defer func() { _ = fsw.Close() }()
+
+	if err := fsw.Add(filepath.Dir(w.path)); err != nil {
+		return fmt.Errorf("could not watch %s: %v", filepath.Dir(w.path), err)
+	}
+
+	last, _ := os.Stat(w.path)
+
+	timer := time.NewTimer(w.stableFor)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("file watcher error: %v", err)
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(ev.Name) != w.path {
+				continue
+			}
+			if ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write) || ev.Has(fsnotify.Rename) || ev.Has(fsnotify.Chmod) {
+				timer.Reset(w.stableFor)
+			}
+		case <-timer.C:
+			info, ok := w.ready(last)
+			if !ok {
+				continue
+			}
+			last = info
+			select {
+			case changed <- w.path:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// ready reports whether the binary is a regular executable file that is not
+// the version last seen.
+func (w *BinaryWatcher) ready(last os.FileInfo) (os.FileInfo, bool) {
+	info, err := os.Stat(w.path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+		return nil, false
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return nil, false
+	}
+	if last != nil && os.SameFile(last, info) && last.ModTime().Equal(info.ModTime()) && last.Size() == info.Size() {
+		return nil, false
+	}
+	return info, true
+}
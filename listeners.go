@@ -0,0 +1,45 @@
+package selfrestart
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/rafa-mori/selfrestart/internal/listener"
+)
+
+// RegisterListener hands ln over to the next process on Restart. The new
+// process gets it back through Listeners or Listen, so the port is never
+// released while the restart is in progress.
+func (sr *SelfRestart) RegisterListener(ln net.Listener) error {
+	if err := sr.listeners.Add(ln); err != nil {
+		return fmt.Errorf("erro ao registrar listener: %v", err)
+	}
+	return nil
+}
+
+// Listen returns the listener inherited from the previous process for
+// network and address, or opens a new one when there is none. Either way the
+// listener is registered for the next restart.
+func (sr *SelfRestart) Listen(network, address string) (net.Listener, error) {
+	ln, err := listener.Take(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao recuperar listeners herdados: %v", err)
+	}
+	if ln == nil {
+		if ln, err = net.Listen(network, address); err != nil {
+			return nil, err
+		}
+	}
+	if err := sr.RegisterListener(ln); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Listeners returns the listeners inherited from the process that called
+// Restart, in the order they were registered there. It returns nil when the
+// process was not started through a restart with listeners.
+func Listeners() ([]net.Listener, error) {
+	return listener.Inherited()
+}
@@ -0,0 +1,29 @@
+package selfrestart
+
+import (
+	gl "github.com/rafa-mori/selfrestart/logger"
+)
+
+// Logger receives the messages of a SelfRestart instance. level is one of
+// "debug", "info", "notice", "success", "warn" or "error".
+type Logger interface {
+	Log(level, msg string)
+}
+
+// LoggerFunc adapts a function to Logger.
+type LoggerFunc func(level, msg string)
+
+// Log calls f.
+func (f LoggerFunc) Log(level, msg string) {
+	f(level, msg)
+}
+
+// NopLogger discards every message.
+var NopLogger Logger = LoggerFunc(func(string, string) {})
+
+// defaultLogger writes through the logger package.
+type defaultLogger struct{}
+
+func (defaultLogger) Log(level, msg string) {
+	gl.Log(level, msg)
+}
@@ -0,0 +1,195 @@
+package selfrestart
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/policy"
+	"github.com/rafa-mori/selfrestart/internal/restart"
+	"github.com/rafa-mori/selfrestart/prompt"
+)
+
+// Option configures a SelfRestart instance created by New.
+type Option func(*SelfRestart)
+
+// WithPrompter sets how the instance asks for consent, for example before
+// installing Go. The default is prompt.Default(): SELFRESTART_ASSUME_YES when
+// set, the terminal otherwise.
+func WithPrompter(p prompt.Prompter) Option {
+	return func(sr *SelfRestart) {
+		if p != nil {
+			sr.prompter = p
+		}
+	}
+}
+
+// RestartMode selects the strategy used by Restart.
+type RestartMode = restart.RestartMode
+
+const (
+	// RestartModeHelper waits for the current process to exit from a detached
+	// helper and starts the binary again. This is the default.
+	RestartModeHelper = restart.RestartModeHelper
+	// RestartModeExec replaces the current process in place with syscall.Exec.
+	RestartModeExec = restart.RestartModeExec
+)
+
+// WithLogger sends the messages of the instance to l instead of the
+// package logger. Use NopLogger to silence them.
+func WithLogger(l Logger) Option {
+	return func(sr *SelfRestart) {
+		if l != nil {
+			sr.logger = l
+		}
+	}
+}
+
+// WithStrategy sets the restart mode used when Restart is not given
+// WithRestartMode. Choosing one also disables the automatic switch to
+// RestartModeExec under systemd.
+func WithStrategy(mode RestartMode) Option {
+	return func(sr *SelfRestart) {
+		sr.mode = mode
+		sr.modeSet = true
+	}
+}
+
+// WithDefaultArgs sets the arguments of the restarted process when Restart
+// is not given WithArgs. By default os.Args[1:] are used.
+func WithDefaultArgs(args ...string) Option {
+	return func(sr *SelfRestart) {
+		sr.args = append([]string{}, args...)
+	}
+}
+
+// WithDefaultEnv sets the environment of the restarted process when Restart
+// is not given WithEnv. By default os.Environ() at restart time is used.
+func WithDefaultEnv(env []string) Option {
+	return func(sr *SelfRestart) {
+		sr.env = append([]string{}, env...)
+	}
+}
+
+// WithDefaultHookTimeout sets the deadline shared by the hooks of each phase
+// of every restart and kill, instead of DefaultHookTimeout.
+func WithDefaultHookTimeout(timeout time.Duration) Option {
+	return func(sr *SelfRestart) {
+		if timeout > 0 {
+			sr.hookTimeout = timeout
+		}
+	}
+}
+
+// WithHooks makes the instance use h, so that several instances, or code
+// that does not hold the instance, share the same lifecycle hooks.
+func WithHooks(h *Hooks) Option {
+	return func(sr *SelfRestart) {
+		if h != nil {
+			sr.hooks = h
+		}
+	}
+}
+
+// WithProcRoot reads process information from a procfs mounted at dir
+// instead of /proc, such as the host's /proc mounted into a container.
+func WithProcRoot(dir string) Option {
+	return func(sr *SelfRestart) {
+		sr.manager.ProcRoot = dir
+	}
+}
+
+// WithLogDir sets the directory of the restart helper log, instead of the
+// per-user cache directory.
+func WithLogDir(dir string) Option {
+	return func(sr *SelfRestart) {
+		sr.logDir = dir
+	}
+}
+
+// RestartOption customizes a single call to Restart.
+type RestartOption func(*restartConfig)
+
+type restartConfig struct {
+	mode        RestartMode
+	modeSet     bool
+	args        []string
+	env         []string
+	ctx         context.Context
+	hookTimeout time.Duration
+	validation  *restart.Validation
+	rollback    *restart.Rollback
+	policy      *policy.Policy
+
+	readyTimeout time.Duration
+}
+
+// newRestartConfig applies opts on top of the instance defaults.
+func (sr *SelfRestart) newRestartConfig(opts []RestartOption) *restartConfig {
+	cfg := &restartConfig{
+		mode:        sr.mode,
+		modeSet:     sr.modeSet,
+		ctx:         context.Background(),
+		hookTimeout: sr.hookTimeout,
+	}
+	switch {
+	case sr.args != nil:
+		cfg.args = append([]string(nil), sr.args...)
+	case len(os.Args) > 1:
+		cfg.args = append([]string(nil), os.Args[1:]...)
+	}
+	if sr.env != nil {
+		cfg.env = append([]string(nil), sr.env...)
+	} else {
+		cfg.env = os.Environ()
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cfg)
+		}
+	}
+	return cfg
+}
+
+// WithRestartMode selects the restart strategy.
+func WithRestartMode(mode RestartMode) RestartOption {
+	return func(cfg *restartConfig) {
+		cfg.mode = mode
+		cfg.modeSet = true
+	}
+}
+
+// WithArgs replaces the arguments passed to the new process. By default the
+// original os.Args[1:] are preserved.
+func WithArgs(args ...string) RestartOption {
+	return func(cfg *restartConfig) {
+		cfg.args = append([]string(nil), args...)
+	}
+}
+
+// WithEnv replaces the environment of the new process. By default the
+// current os.Environ() is preserved.
+func WithEnv(env []string) RestartOption {
+	return func(cfg *restartConfig) {
+		cfg.env = append([]string(nil), env...)
+	}
+}
+
+// WithContext sets the parent context of the restart hooks.
+func WithContext(ctx context.Context) RestartOption {
+	return func(cfg *restartConfig) {
+		if ctx != nil {
+			cfg.ctx = ctx
+		}
+	}
+}
+
+// WithHookTimeout sets the deadline shared by the hooks of each phase of the
+// restart, overriding the instance default.
+func WithHookTimeout(timeout time.Duration) RestartOption {
+	return func(cfg *restartConfig) {
+		if timeout > 0 {
+			cfg.hookTimeout = timeout
+		}
+	}
+}
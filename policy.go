@@ -0,0 +1,43 @@
+package selfrestart
+
+import (
+	"github.com/rafa-mori/selfrestart/internal/policy"
+)
+
+// RestartPolicy is a declarative restart policy modelled after container
+// runtimes. See DefaultRestartPolicy and LoadRestartPolicy.
+type RestartPolicy = policy.Policy
+
+// PolicyMode selects when a restart policy restarts.
+type PolicyMode = policy.Mode
+
+// PolicyDuration is a duration written as a string ("30s") in policy files.
+type PolicyDuration = policy.Duration
+
+const (
+	PolicyAlways        = policy.Always
+	PolicyOnFailure     = policy.OnFailure
+	PolicyUnlessStopped = policy.UnlessStopped
+	PolicyNever         = policy.Never
+)
+
+// DefaultRestartPolicy restarts on failure, at most five times a minute,
+// backing off from one to thirty seconds.
+func DefaultRestartPolicy() RestartPolicy {
+	return policy.Default()
+}
+
+// LoadRestartPolicy reads a JSON restart policy file.
+func LoadRestartPolicy(path string) (RestartPolicy, error) {
+	return policy.LoadFile(path)
+}
+
+// WithRestartPolicy retries a failed Restart according to p. Failures count
+// as a non-zero exit; a restart canceled by a hook counts as stopped. A
+// failed restart has no exit code of its own, so Restart rejects a policy
+// with ExitCodes.
+func WithRestartPolicy(p RestartPolicy) RestartOption {
+	return func(cfg *restartConfig) {
+		cfg.policy = &p
+	}
+}
@@ -0,0 +1,188 @@
+// Package prompt decides how SelfRestart asks for consent, so that
+// interactive use, CI and daemons without a terminal can each get the
+// behaviour they need.
+package prompt
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// EnvAssumeYes answers every question when set: "1", "true", "yes" or "y"
+// mean yes and "0", "false", "no" or "n" mean no.
+const EnvAssumeYes = "SELFRESTART_ASSUME_YES"
+
+// DefaultTTY is the terminal TTY reads answers from.
+const DefaultTTY = "/dev/tty"
+
+var (
+	// ErrNoTTY is returned by TTY when there is no terminal to ask on.
+	ErrNoTTY = errors.New("no terminal available for confirmation")
+	// ErrNoAnswer is returned by Fake when it ran out of answers.
+	ErrNoAnswer = errors.New("no answer configured")
+)
+
+// Prompter asks a yes/no question. defaultYes is the answer given to an
+// empty reply. An error means no consent was obtained; callers should treat
+// it as no.
+type Prompter interface {
+	Confirm(ctx context.Context, question string, defaultYes bool) (bool, error)
+}
+
+// Default returns the prompter used when none is configured: EnvAssumeYes
+// when set, the terminal otherwise.
+func Default() Prompter {
+	return Env{Var: EnvAssumeYes, Fallback: &TTY{}}
+}
+
+// Fixed answers every question with its own value.
+type Fixed bool
+
+const (
+	// AssumeYes consents to everything.
+	AssumeYes = Fixed(true)
+	// AssumeNo refuses everything.
+	AssumeNo = Fixed(false)
+)
+
+// Confirm returns the fixed answer.
+func (f Fixed) Confirm(context.Context, string, bool) (bool, error) {
+	return bool(f), nil
+}
+
+// Env answers from an environment variable and defers to Fallback when it
+// is unset or empty.
+type Env struct {
+	// Var is the variable to read; empty means EnvAssumeYes.
+	Var string
+	// Fallback asks when the variable is unset; nil refuses.
+	Fallback Prompter
+}
+
+// Confirm answers from the environment or the fallback.
+func (e Env) Confirm(ctx context.Context, question string, defaultYes bool) (bool, error) {
+	name := e.Var
+	if name == "" {
+		name = EnvAssumeYes
+	}
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		if e.Fallback == nil {
+			return false, nil
+		}
+		return e.Fallback.Confirm(ctx, question, defaultYes)
+	}
+	answer, ok := parseAnswer(value)
+	if !ok {
+		return false, fmt.Errorf("invalid %s value %q", name, value)
+	}
+	return answer, nil
+}
+
+// TTY asks on the controlling terminal. It never reads the process stdin,
+// so it neither blocks on pipes nor competes with the application for it,
+// and fails with ErrNoTTY when the process has no terminal.
+type TTY struct {
+	// Path of the terminal device; empty means DefaultTTY.
+	Path string
+	// Out receives the question; nil writes to the terminal itself.
+	Out io.Writer
+}
+
+// Confirm prints question and reads a reply until ctx is done. An
+// unrecognized reply counts as no.
+func (t *TTY) Confirm(ctx context.Context, question string, defaultYes bool) (bool, error) {
+	path := t.Path
+	if path == "" {
+		path = DefaultTTY
+	}
+	tty, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrNoTTY, err)
+	}
+	// Closing the terminal also unblocks the reader below
+	var closeOnce sync.Once
+	closeTTY := func() { closeOnce.Do(func() { _ = tty.Close() }) }
+	defer closeTTY()
+
+	out := t.Out
+	if out == nil {
+		out = tty
+	}
+	hint := "[y/N]"
+	if defaultYes {
+		hint = "[Y/n]"
+	}
+	if _, err := fmt.Fprintf(out, "%s %s ", question, hint); err != nil {
+		return false, err
+	}
+
+	type reply struct {
+		line string
+		err  error
+	}
+	replies := make(chan reply, 1)
+	go func() {
+		line, err := bufio.NewReader(tty).ReadString('\n')
+		replies <- reply{line, err}
+	}()
+
+	select {
+	case r := <-replies:
+		if r.err != nil && r.line == "" {
+			return false, r.err
+		}
+		line := strings.TrimSpace(r.line)
+		if line == "" {
+			return defaultYes, nil
+		}
+		answer, _ := parseAnswer(line)
+		return answer, nil
+	case <-ctx.Done():
+		closeTTY()
+		_, _ = fmt.Fprintln(out)
+		return false, ctx.Err()
+	}
+}
+
+// Fake replays scripted answers and records the questions, for tests.
+type Fake struct {
+	mu        sync.Mutex
+	Answers   []bool
+	Err       error
+	Questions []string
+}
+
+// Confirm records question and returns the next answer, Err when set, or
+// ErrNoAnswer when the answers ran out.
+func (f *Fake) Confirm(_ context.Context, question string, _ bool) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Questions = append(f.Questions, question)
+	if f.Err != nil {
+		return false, f.Err
+	}
+	if len(f.Answers) == 0 {
+		return false, ErrNoAnswer
+	}
+	answer := f.Answers[0]
+	f.Answers = f.Answers[1:]
+	return answer, nil
+}
+
+// parseAnswer interprets yes/no style words.
+func parseAnswer(s string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true", "yes", "y", "sim", "s":
+		return true, true
+	case "0", "false", "no", "n", "não", "nao":
+		return false, true
+	}
+	return false, false
+}
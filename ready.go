@@ -0,0 +1,53 @@
+package selfrestart
+
+import (
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/restart"
+	"github.com/rafa-mori/selfrestart/internal/systemd"
+)
+
+// DefaultReadyTimeout is a sensible deadline for WithReadiness.
+const DefaultReadyTimeout = 30 * time.Second
+
+var (
+	// ErrNotReady is wrapped by Restart when the new process did not call
+	// Ready before the deadline.
+	ErrNotReady = restart.ErrNotReady
+	// ErrChildExited is wrapped by Restart when the new process exited before
+	// calling Ready.
+	ErrChildExited = restart.ErrChildExited
+)
+
+// ReadinessError is returned by Restart when the new process never became
+// ready. Use errors.As to inspect it and errors.Is to tell ErrNotReady from
+// ErrChildExited.
+type ReadinessError = restart.ReadinessError
+
+// WithReadiness starts the new process right away instead of after the caller
+// exits and blocks until it calls Ready or timeout expires. On failure the new
+// process is killed, the current one keeps its listeners and Restart returns
+// a *ReadinessError. Only available in helper mode.
+func WithReadiness(timeout time.Duration) RestartOption {
+	return func(cfg *restartConfig) {
+		if timeout <= 0 {
+			timeout = DefaultReadyTimeout
+		}
+		cfg.readyTimeout = timeout
+	}
+}
+
+// Ready tells the process that restarted us with WithReadiness that
+// initialization is complete. Call it once listeners are open; it is a no-op
+// when the process was started any other way. Resources the previous process
+// still holds exclusively, such as a pidfile, only become free after Ready.
+// Under systemd it also sends READY=1 to the notify socket.
+func Ready() error {
+	if err := restart.NotifyReady(); err != nil {
+		return err
+	}
+	if n := systemd.NewNotifier(); n.Enabled() {
+		return n.Notify(systemd.StateReady)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package selfrestart
+
+import (
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/restart"
+)
+
+// RestartOutcome is the result recorded after a restart with rollback.
+type RestartOutcome = restart.Outcome
+
+// Outcome values found in RestartOutcome.Result.
+const (
+	OutcomeCommitted  = restart.OutcomeCommitted
+	OutcomeRolledBack = restart.OutcomeRolledBack
+	OutcomeFailed     = restart.OutcomeFailed
+)
+
+// WithRollback keeps a backup of the running binary and has the helper watch
+// the new process for grace. If it exits in that window, or healthCommand
+// (when given) fails at the end of it, the backup is put back and started
+// again. Only available in helper mode; under systemd that mode has to be
+// chosen explicitly with WithRestartMode.
+func WithRollback(grace time.Duration, healthCommand ...string) RestartOption {
+	return func(cfg *restartConfig) {
+		cfg.rollback = &restart.Rollback{
+			Grace:         grace,
+			HealthCommand: append([]string(nil), healthCommand...),
+		}
+	}
+}
+
+// LastRestartOutcome returns the outcome of the last restart with rollback
+// of the binary at binPath.
+func LastRestartOutcome(binPath string) (*RestartOutcome, error) {
+	return restart.LoadOutcome(restart.StatePath(binPath))
+}
@@ -0,0 +1,35 @@
+package selfrestart
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/restart"
+)
+
+func TestRollbackUnderSystemdNeedsExplicitMode(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "notify.sock"))
+	hooks := NewHooks()
+	ran := false
+	hooks.OnBeforeRestart(func(ctx context.Context) error { ran = true; return nil })
+	sr := New(WithHooks(hooks), WithLogger(&recordingLogger{}))
+
+	err := sr.Restart(WithRollback(time.Second))
+	if err == nil || !strings.Contains(err.Error(), "WithRestartMode") {
+		t.Fatalf("Restart = %v, want an error asking for an explicit mode", err)
+	}
+	if ran {
+		t.Fatal("OnBeforeRestart hooks ran before the rollback was refused")
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(restart.BackupPath(bin)); !os.IsNotExist(err) {
+		t.Fatalf("backup written for a refused restart: %v", err)
+	}
+}
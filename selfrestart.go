@@ -1,17 +1,23 @@
 package selfrestart
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
-	"strings"
+	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/rafa-mori/selfrestart/internal/install"
+	"github.com/rafa-mori/selfrestart/internal/listener"
 	"github.com/rafa-mori/selfrestart/internal/platform"
+	"github.com/rafa-mori/selfrestart/internal/policy"
 	"github.com/rafa-mori/selfrestart/internal/process"
 	"github.com/rafa-mori/selfrestart/internal/restart"
-	gl "github.com/rafa-mori/selfrestart/logger"
+	"github.com/rafa-mori/selfrestart/internal/systemd"
+	"github.com/rafa-mori/selfrestart/prompt"
 )
 
 // SelfRestart provides functionality for automatic process restart
@@ -19,15 +25,47 @@ type SelfRestart struct {
 	installer *install.Installer
 	manager   *process.ProcessManager
 	restarter *restart.Restarter
+	listeners *listener.Registry
+	hooks     *Hooks
+	notifier  *systemd.Notifier
+	prompter  prompt.Prompter
+	logger    Logger
+
+	// Defaults of every Restart, overridden per call by RestartOptions
+	mode        RestartMode
+	modeSet     bool
+	args        []string
+	env         []string
+	hookTimeout time.Duration
+	logDir      string
+
+	// Active WatchSignals loops that run the shutdown hooks on SIGINT
+	interruptWatchers atomic.Int32
 }
 
-// New creates a new SelfRestart instance
-func New() *SelfRestart {
-	return &SelfRestart{
+// New creates a new SelfRestart instance configured by opts
+func New(opts ...Option) *SelfRestart {
+	sr := &SelfRestart{
 		installer: install.NewInstaller(),
 		manager:   process.NewProcessManager(),
 		restarter: restart.NewRestarter(),
+		listeners: listener.NewRegistry(),
+		hooks:     NewHooks(),
+		notifier:  systemd.NewNotifier(),
+		prompter:  prompt.Default(),
+		logger:    defaultLogger{},
+
+		mode:        RestartModeHelper,
+		hookTimeout: DefaultHookTimeout,
 	}
+	// Helpers and probes run the service binary but are not the service
+	sr.manager.SkipArgs = []string{restart.HelperCommand, restart.ProbeFlag}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(sr)
+		}
+	}
+	return sr
 }
 
 // Module provides access to module information
@@ -52,88 +90,136 @@ func (m *ModuleInfo) GetRepoName() string {
 	return m.repoName
 }
 
-// IsGolangInstalled checks if Go is installed and prompts for automatic installation if needed
+// GoInstallation describes the Go toolchain found by DetectGo.
+type GoInstallation = install.GoInstallation
+
+// GoStatus values found in GoInstallation.Status.
+const (
+	GoOK      = install.GoOK
+	GoTooOld  = install.GoTooOld
+	GoMissing = install.GoMissing
+)
+
+// SetMinimumGoVersion sets the oldest Go version, such as "1.24" or
+// "1.24.4", accepted by IsGolangInstalled and DetectGo.
+func (sr *SelfRestart) SetMinimumGoVersion(minimum string) {
+	sr.installer.MinimumVersion = minimum
+}
+
+// DetectGo reports the go command found in PATH, its version and whether it
+// meets the minimum version
+func (sr *SelfRestart) DetectGo() (*GoInstallation, error) {
+	return sr.installer.DetectGo(context.Background())
+}
+
+// IsGolangInstalled checks if a recent enough Go is installed and prompts for automatic installation if needed
 func (sr *SelfRestart) IsGolangInstalled() bool {
-	isInstalled, err := sr.installer.IsInPath(Module.GetCommandEntry())
+	inst, err := sr.DetectGo()
 	if err != nil {
-		gl.Log("error", fmt.Sprintf("Erro ao verificar instalação do Go: %v", err))
-		return false
+		sr.logger.Log("error", fmt.Sprintf("Erro ao verificar instalação do Go: %v", err))
 	}
-	
-	if isInstalled {
+
+	switch inst.Status {
+	case GoOK:
 		return true
+	case GoTooOld:
+		sr.logger.Log("warn", fmt.Sprintf("Go %s em %s é mais antigo que o mínimo exigido (%s)", inst.Version, inst.Path, inst.Minimum))
 	}
 
-	return sr.promptForGoInstallation()
+	if !sr.promptForGoInstallation() {
+		return false
+	}
+	installed, err := sr.InstallGo()
+	if err != nil {
+		sr.logger.Log("error", err.Error())
+	}
+	return installed
 }
 
-// promptForGoInstallation displays installation prompt and handles user response
+// goInstallTimeout bounds how long the Go installation prompt waits
+const goInstallTimeout = 15 * time.Second
+
+// promptForGoInstallation asks the prompter whether Go may be installed
 func (sr *SelfRestart) promptForGoInstallation() bool {
+	sr.logger.Log("warn", "O Go não está instalado, não foi encontrado no PATH ou é antigo demais.")
+	sr.logger.Log("info", fmt.Sprintf("Também é possível usar a versão já compilada: https://github.com/%s/releases/latest", Module.GetRepoName()))
 
-	// Exibe uma mensagem de aviso ao usuário
-	fmt.Printf("\033[1;36m%s\033[0m", `
-┌──────────────────────────────────────────────────────────────┐
-│                                                              │
-│  O Go não está instalado ou não foi encontrado no PATH.      │
-│                                                              │
-│  Para continuar, precisamos instalá-lo.                      │
-│  Podemos instalar o Go automaticamente, mas você precisa     │
-│  confirmar se deseja prosseguir.                             │
-│                                                              │
-│  Se você deseja instalar o Go automaticamente,               │
-│  pressione 'Y' e 'Enter'.                                    │
-│  Caso contrário, pressione 'N' e 'Enter' para sair.          │
-│                                                              │
-└──────────────────────────────────────────────────────────────┘`)
-	fmt.Printf("\033[1;36m%s\033[0m", `
-
-  Se você não tem certeza se deseja instalar o Go,            
-  pode instalar a versão já compilada diretamente do repo:    
-                                                              
-  https://github.com/`+Module.GetRepoName()+`/releases/latest   
-
-  `)
-
-	fmt.Printf("\033[1;33m%s\033[0m", `
-┌──────────────────────────────────────────────────────────────┐
-│                                                              │
-│  Pressione 'Y' para sim, instalar o Go automaticamente,      │
-│  ou 'N' para sair do programa. (15 segundos para resposta)   │
-│                                                              │
-└──────────────────────────────────────────────────────────────┘
-`)
-
-	fmt.Print("Resposta: ")
-
-	// Canal para receber a resposta do usuário
-	// O canal é usado para evitar o bloqueio do terminal enquanto espera a entrada do usuário
-	// e permite que o programa continue executando.
-	responseCh := make(chan string, 1)
-	defer close(responseCh)
-
-	// Lê a resposta do usuário em uma goroutine
-	// Isso permite que o programa continue executando enquanto espera a entrada do usuário
-	// e evita o bloqueio do terminal. O usuário pode pressionar 'Y' ou 'N', caso contrário
-	// o programa irá aguardar 15 segundos antes de encerrar.
-	go func() {
-		reader := bufio.NewReader(os.Stdin)
-		response, _ := reader.ReadString('\n')
-		response = strings.TrimSpace(strings.ToUpper(response))
-		responseCh <- response
-	}()
-
-	// Aguarda a resposta do usuário ou timeout de 15 segundos
-	select {
-	case response := <-responseCh:
-		return response == "Y"
-	case <-time.After(15 * time.Second):
-		fmt.Println("\nTempo esgotado. Docker não será ativado.")
+	ctx, cancel := context.WithTimeout(context.Background(), goInstallTimeout)
+	defer cancel()
+	ok, err := sr.prompter.Confirm(ctx, "Instalar o Go automaticamente?", false)
+	if err != nil {
+		sr.logger.Log("warn", fmt.Sprintf("Sem confirmação para instalar o Go: %v", err))
 		return false
 	}
+	return ok
+}
+
+// Confirm asks the configured prompter a yes/no question
+func (sr *SelfRestart) Confirm(ctx context.Context, question string, defaultYes bool) (bool, error) {
+	return sr.prompter.Confirm(ctx, question, defaultYes)
+}
+
+// Restart restarts the current process. By default a detached helper brings
+// the binary back up once the caller exits; pass WithRestartMode(RestartModeExec)
+// to replace the process in place instead. The original arguments and
+// environment are preserved unless WithArgs or WithEnv override them.
+// Listeners registered with RegisterListener or Listen are passed to the new
+// process; in helper mode they are closed here once handed off, so the caller
+// only has to drain in-flight work before exiting.
+//
+// Hooks registered with OnBeforeRestart run first and any error cancels the
+// restart with ErrRestartCanceled. With WithValidation or WithProbe the binary
+// is checked next and a failure aborts with a *ValidationError. OnShutdown
+// hooks run once the new process is on its way; their errors are only logged.
+// Each hook phase gets its own deadline, and neither counts the validation
+// or the start of the new process. WithRollback keeps the previous binary
+// around and restores it if the new process does not survive.
+//
+// WithReadiness overlaps the two processes: Restart returns only once the new
+// one has called Ready, or fails with a *ReadinessError and leaves the
+// current process untouched.
+//
+// Under systemd the unit is told RELOADING=1 while the restart is in flight.
+// A detached helper would be killed along with the unit's main process, so
+// unless a mode is chosen explicitly the restart uses RestartModeExec there;
+// with WithReadiness the new process is announced through MAINPID. Rollback
+// needs the helper, so WithRollback under systemd fails unless helper mode is
+// chosen explicitly for a unit that lets it outlive the main process.
+//
+// WithRestartPolicy retries a failed restart according to the policy.
+func (sr *SelfRestart) Restart(opts ...RestartOption) error {
+	cfg := sr.newRestartConfig(opts)
+	if cfg.policy == nil {
+		return sr.restart(cfg)
+	}
+	if err := cfg.policy.Validate(); err != nil {
+		return err
+	}
+	if len(cfg.policy.ExitCodes) > 0 {
+		return fmt.Errorf("a política de reinício não pode filtrar códigos de saída: falhas de Restart não têm código")
+	}
+
+	tracker := policy.NewTracker(*cfg.policy)
+	for {
+		err := sr.restart(cfg)
+		if err == nil {
+			return nil
+		}
+		decision := tracker.Decide(policy.Exit{Code: 1, Stopped: errors.Is(err, ErrRestartCanceled)})
+		if !decision.Restart {
+			return err
+		}
+		sr.logger.Log("warn", fmt.Sprintf("Falha no reinício (%v), tentativa %d em %s", err, decision.Attempt, decision.Delay))
+		select {
+		case <-cfg.ctx.Done():
+			return err
+		case <-time.After(decision.Delay):
+		}
+	}
 }
 
-// Restart restarts the current process
-func (sr *SelfRestart) Restart() error {
+// restart performs a single restart attempt
+func (sr *SelfRestart) restart(cfg *restartConfig) error {
 	binPath, err := sr.getCurrentBinaryPath()
 	if err != nil {
 		return fmt.Errorf("erro ao obter caminho do binário atual: %v", err)
@@ -144,11 +230,129 @@ func (sr *SelfRestart) Restart() error {
 		return fmt.Errorf("PID inválido: %d", pid)
 	}
 
-	gl.Log("info", fmt.Sprintf("Reiniciando processo %d com binário %s", pid, binPath))
+	if sr.notifier.Enabled() && !cfg.modeSet {
+		// O systemd encerra o helper junto com o processo principal, e o
+		// rollback depende do helper para vigiar o novo processo
+		if cfg.rollback != nil {
+			return fmt.Errorf("rollback sob o systemd exige WithRestartMode(%s) explícito e KillMode=process na unidade", RestartModeHelper)
+		}
+		if cfg.readyTimeout <= 0 {
+			cfg.mode = RestartModeExec
+		}
+	}
+
+	sr.logger.Log("info", fmt.Sprintf("Reiniciando processo %d com binário %s (modo: %s)", pid, binPath, cfg.mode))
+
+	if err := sr.runBeforeRestartHooks(cfg); err != nil {
+		return err
+	}
+
+	// Valida o novo binário antes de liberar o processo atual; a validação
+	// tem o próprio timeout e não consome o prazo dos hooks
+	if cfg.validation != nil {
+		validation := *cfg.validation
+		if validation.Env == nil {
+			validation.Env = cfg.env
+		}
+		if err := sr.restarter.Validate(cfg.ctx, binPath, validation); err != nil {
+			return fmt.Errorf("reinício abortado: %w", err)
+		}
+	}
+
+	// Guarda uma cópia do binário em execução para um eventual rollback
+	if cfg.rollback != nil {
+		if cfg.mode == RestartModeExec {
+			return fmt.Errorf("rollback só é suportado no modo %s", RestartModeHelper)
+		}
+		if cfg.readyTimeout > 0 {
+			return fmt.Errorf("rollback não pode ser combinado com a confirmação de prontidão")
+		}
+		cfg.rollback.BackupPath = restart.BackupPath(binPath)
+		cfg.rollback.StatePath = restart.StatePath(binPath)
+		if err := sr.restarter.Backup(binPath, cfg.rollback.BackupPath); err != nil {
+			return fmt.Errorf("erro ao criar backup do binário: %v", err)
+		}
+	}
+
+	// Descritores dos listeners registrados, repassados ao novo processo
+	files, addrs, err := sr.listeners.Files()
+	if err != nil {
+		return fmt.Errorf("erro ao preparar listeners para o reinício: %v", err)
+	}
+	defer listener.CloseFiles(files)
+
+	spec := restart.Spec{
+		PID:      pid,
+		BinPath:  binPath,
+		Args:     cfg.args,
+		Env:      listener.WithEnv(cfg.env, ""),
+		Rollback: cfg.rollback,
+		Dir:      workingDir(),
+		LogPath:  sr.helperLogPath(),
+	}
+
+	if sr.notifier.Enabled() {
+		if err := sr.notifier.Reloading(); err != nil {
+			sr.logger.Log("warn", fmt.Sprintf("Erro ao notificar o systemd: %v", err))
+		}
+	}
+	err = sr.handoff(cfg, spec, files, addrs)
+	if err != nil {
+		// O processo atual continua servindo
+		sr.notifySystemd(systemd.StateReady)
+	}
+	return err
+}
 
-	// Cria e executa o script de reinício
-	if err := sr.restarter.CreateAndExecRestartScript(pid, binPath); err != nil {
-		return fmt.Errorf("erro ao criar e executar script de reinício: %v", err)
+// handoff brings up the new process described by spec according to cfg.mode
+func (sr *SelfRestart) handoff(cfg *restartConfig, spec restart.Spec, files []*os.File, addrs []net.Addr) error {
+	switch cfg.mode {
+	case RestartModeExec:
+		if cfg.readyTimeout > 0 {
+			return fmt.Errorf("confirmação de prontidão só é suportada no modo %s", RestartModeHelper)
+		}
+		fds, err := listener.InheritableFDs(files)
+		if err != nil {
+			return fmt.Errorf("erro ao preparar listeners para exec: %v", err)
+		}
+		if len(fds) > 0 {
+			spec.Env = listener.WithEnv(cfg.env, listener.Encode(fds, addrs))
+		}
+		sr.runRestartShutdownHooks(cfg)
+		// Substitui a imagem do processo atual; só retorna em caso de erro
+		if err := sr.restarter.ExecRestart(spec); err != nil {
+			listener.ReleaseFDs(fds)
+			return fmt.Errorf("erro ao executar reinício via exec: %v", err)
+		}
+	case RestartModeHelper, "":
+		if len(files) > 0 {
+			fds := make([]int, len(files))
+			for i := range files {
+				fds[i] = listener.FirstExtraFD + i
+			}
+			spec.Files = files
+			spec.Env = listener.WithEnv(cfg.env, listener.Encode(fds, addrs))
+		}
+		if cfg.readyTimeout > 0 {
+			// Sobe o novo processo já e espera que ele confirme a prontidão
+			newPID, err := sr.restarter.StartAndWaitReady(spec, cfg.readyTimeout)
+			if err != nil {
+				return fmt.Errorf("reinício abortado: %w", err)
+			}
+			sr.logger.Log("info", fmt.Sprintf("Novo processo %d confirmou prontidão", newPID))
+			sr.notifySystemd(fmt.Sprintf("MAINPID=%d", newPID), systemd.StateReady)
+		} else if err := sr.restarter.StartHelper(spec); err != nil {
+			// Inicia o helper que sobe o novo processo após a saída deste
+			return fmt.Errorf("erro ao iniciar o helper de reinício: %v", err)
+		}
+		// O helper mantém os sockets abertos; aqui apenas paramos de aceitar
+		// conexões para que o chamador possa drenar as requisições e sair.
+		if err := sr.listeners.Close(); err != nil {
+			sr.logger.Log("warn", fmt.Sprintf("Erro ao fechar listeners após o repasse: %v", err))
+		}
+		sr.runRestartShutdownHooks(cfg)
+	default:
+		return fmt.Errorf("modo de reinício desconhecido: %s", cfg.mode)
 	}
 
 	return nil
@@ -159,19 +363,151 @@ func (sr *SelfRestart) GetCurrentPID() int {
 	return sr.manager.GetCurrentPID()
 }
 
-// KillCurrentProcess kills the current process
+// KillCurrentProcess runs the OnShutdown hooks and then kills the current
+// process. When WatchSignals handles SIGINT, the watcher runs the hooks on
+// the interrupt instead, so they only run once.
 func (sr *SelfRestart) KillCurrentProcess() error {
+	if sr.interruptWatchers.Load() == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), sr.hookTimeout)
+		sr.notifySystemd(systemd.StateStopping)
+		sr.runShutdownHooks(ctx)
+		cancel()
+	}
 	return sr.manager.KillCurrentProcess()
 }
 
+// TerminateOptions configures Terminate; the zero value sends SIGTERM and
+// then SIGKILL.
+type TerminateOptions = process.TerminateOptions
+
+// TerminateStep is one signal of a termination sequence.
+type TerminateStep = process.Step
+
+// TerminateResult describes what Terminate did.
+type TerminateResult = process.TerminateResult
+
+// ErrStillRunning is returned by Terminate when the process outlived every step.
+var ErrStillRunning = process.ErrStillRunning
+
+// DefaultTerminateSteps sends SIGTERM, waits up to 10s and then sends SIGKILL.
+func DefaultTerminateSteps() []TerminateStep {
+	return process.DefaultSteps()
+}
+
+// Terminate stops the process with the given PID by sending the signal
+// sequence of opts, waiting after each signal for it to exit
+func (sr *SelfRestart) Terminate(pid int, opts TerminateOptions) (*TerminateResult, error) {
+	return sr.manager.Terminate(pid, opts)
+}
+
+// runBeforeRestartHooks runs the OnBeforeRestart hooks under a deadline of
+// their own, released before the binary is validated
+func (sr *SelfRestart) runBeforeRestartHooks(cfg *restartConfig) error {
+	ctx, cancel := context.WithTimeout(cfg.ctx, cfg.hookTimeout)
+	defer cancel()
+	return sr.hooks.runBeforeRestart(ctx, sr.logger)
+}
+
+// runRestartShutdownHooks runs the OnShutdown hooks of a restart under a
+// deadline of their own, so the time spent bringing up the new process, such
+// as a readiness wait, does not use up their budget
+func (sr *SelfRestart) runRestartShutdownHooks(cfg *restartConfig) {
+	ctx, cancel := context.WithTimeout(cfg.ctx, cfg.hookTimeout)
+	defer cancel()
+	sr.runShutdownHooks(ctx)
+}
+
+// runShutdownHooks runs the OnShutdown hooks and logs their errors
+func (sr *SelfRestart) runShutdownHooks(ctx context.Context) {
+	if err := sr.hooks.runShutdown(ctx, sr.logger); err != nil {
+		sr.logger.Log("warn", fmt.Sprintf("Erro nos hooks de encerramento: %v", err))
+	}
+}
+
 // IsProcessRunning checks if a process with the given PID is running
 func (sr *SelfRestart) IsProcessRunning(pid int) (bool, error) {
 	return sr.manager.IsProcessRunning(pid)
 }
 
-// InstallGo installs Go on Unix systems
+// ProcessInfo describes a process as seen in /proc.
+type ProcessInfo = process.ProcessInfo
+
+// Inspect returns the executable, arguments, start time, memory, threads,
+// open descriptors, parent and state of the process with the given PID.
+// Linux only.
+func (sr *SelfRestart) Inspect(pid int) (ProcessInfo, error) {
+	return sr.manager.Inspect(pid)
+}
+
+// ProcessMatch is a process found by FindByName or FindByExecutable.
+type ProcessMatch = process.Match
+
+// FindByName returns the processes called name, other than the current one
+// and restart helpers or probes. Linux only.
+func (sr *SelfRestart) FindByName(name string) ([]ProcessMatch, error) {
+	return sr.manager.FindByName(name)
+}
+
+// FindByExecutable returns the processes running the binary at path, other
+// than the current one and restart helpers or probes. Linux only.
+func (sr *SelfRestart) FindByExecutable(path string) ([]ProcessMatch, error) {
+	return sr.manager.FindByExecutable(path)
+}
+
+// PIDFile is a locked pidfile owned by the current process.
+type PIDFile = process.PIDFile
+
+// PIDFilePath returns the default pidfile of the service called name.
+func PIDFilePath(name string) string {
+	return process.PIDFilePath(name)
+}
+
+// AcquirePIDFile locks the pidfile at path and records the current PID in it,
+// refusing to proceed when another live instance holds it.
+func (sr *SelfRestart) AcquirePIDFile(path string) (*PIDFile, error) {
+	return sr.manager.AcquirePIDFile(path, sr.manager.GetCurrentPID())
+}
+
+// ReadPIDFile returns the PID recorded at path, removing the file when that
+// process is gone.
+func (sr *SelfRestart) ReadPIDFile(path string) (int, error) {
+	return sr.manager.ReadPIDFile(path)
+}
+
+// GetExecutablePath returns the executable of the process with the given PID
+func (sr *SelfRestart) GetExecutablePath(pid int) (string, error) {
+	return sr.manager.ExecutablePath(pid)
+}
+
+// GoToolchain is a Go toolchain installed by InstallGoVersion.
+type GoToolchain = install.Toolchain
+
+// InstallGo installs the minimum Go version unless a recent enough one is
+// already in PATH
 func (sr *SelfRestart) InstallGo() (bool, error) {
-	return sr.installer.InstallGoUnix()
+	inst, err := sr.DetectGo()
+	if err == nil && inst.Status == GoOK {
+		return true, nil
+	}
+	if _, err := sr.InstallGoVersion(context.Background(), inst.Minimum); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// InstallGoVersion downloads and verifies the requested Go version ("1.24",
+// "1.24.4", "latest"...) for the host platform, installs it into a user
+// prefix and puts it first in PATH for the current process. Set
+// SELFRESTART_GO_MIRROR to download from a mirror.
+func (sr *SelfRestart) InstallGoVersion(ctx context.Context, version string) (*GoToolchain, error) {
+	sr.logger.Log("info", fmt.Sprintf("Instalando Go %s em %s", version, sr.installer.Toolchains.Prefix))
+	t, err := sr.installer.InstallGo(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao instalar o Go: %w", err)
+	}
+	sr.logger.Log("success", fmt.Sprintf("Go %s instalado em %s", t.Version, t.GOROOT))
+	sr.logger.Log("info", fmt.Sprintf("Para usá-lo em novos terminais, adicione ao %s: export PATH=\"%s:$PATH\"", install.GetUserShellRC(), t.Bin()))
+	return t, nil
 }
 
 // GetPlatformInfo returns information about the current platform
@@ -188,9 +524,19 @@ func (sr *SelfRestart) getCurrentBinaryPath() (string, error) {
 	return binPath, nil
 }
 
-func init() {
-	gl.Log("notice", "Inicializando módulo de reinício automático")
-	gl.SetDebug(true) // Ativa o modo de depuração para logs
-	gl.Log("notice", "Módulo de reinício automático inicializado com sucesso")
-	gl.Log("info", "selfrestart Versão: v1.0.0")
+// workingDir returns the current working directory, or "" to inherit it
+func workingDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// helperLogPath returns the log file of the restart helper, if configured
+func (sr *SelfRestart) helperLogPath() string {
+	if sr.logDir == "" {
+		return ""
+	}
+	return filepath.Join(sr.logDir, restart.HelperLogName)
 }
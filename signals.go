@@ -0,0 +1,145 @@
+package selfrestart
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/systemd"
+)
+
+// EventType identifies a lifecycle event reported by WatchSignals.
+type EventType string
+
+const (
+	// EventRestarting is sent when a restart signal is received.
+	EventRestarting EventType = "restarting"
+	// EventRestarted is sent once Restart handed off to the new process. The
+	// caller should exit after draining its work.
+	EventRestarted EventType = "restarted"
+	// EventRestartFailed is sent when Restart returned an error. The watcher
+	// keeps running.
+	EventRestartFailed EventType = "restart_failed"
+	// EventReload is sent when a reload signal is received, after OnReload ran.
+	EventReload EventType = "reload"
+	// EventTerminating is sent when a terminate signal is received.
+	EventTerminating EventType = "terminating"
+	// EventTerminated is sent once the shutdown hooks ran. The caller should exit.
+	EventTerminated EventType = "terminated"
+)
+
+// Event describes something that happened in the signal watcher.
+type Event struct {
+	Type   EventType
+	Signal os.Signal
+	Err    error
+	Time   time.Time
+}
+
+// SignalOptions configures WatchSignals. Empty signal lists fall back to the
+// platform defaults: SIGUSR1 to restart, SIGHUP to reload and SIGINT/SIGTERM
+// to terminate on Unix.
+type SignalOptions struct {
+	RestartSignals   []os.Signal
+	ReloadSignals    []os.Signal
+	TerminateSignals []os.Signal
+
+	// RestartOptions are passed to every Restart triggered by a signal.
+	RestartOptions []RestartOption
+	// OnReload runs when a reload signal is received.
+	OnReload Hook
+}
+
+// WatchSignals installs handlers for the restart, reload and terminate
+// signals and returns a channel of lifecycle events. Restart signals call
+// Restart; terminate signals run the OnShutdown hooks. The channel is closed
+// after EventRestarted or EventTerminated, or when ctx is done.
+func (sr *SelfRestart) WatchSignals(ctx context.Context, opts SignalOptions) <-chan Event {
+	restartSigs := signalsOrDefault(opts.RestartSignals, defaultRestartSignals)
+	reloadSigs := signalsOrDefault(opts.ReloadSignals, defaultReloadSignals)
+	terminateSigs := signalsOrDefault(opts.TerminateSignals, defaultTerminateSignals)
+
+	all := make([]os.Signal, 0, len(restartSigs)+len(reloadSigs)+len(terminateSigs))
+	all = append(append(append(all, restartSigs...), reloadSigs...), terminateSigs...)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, all...)
+
+	ownsInterrupt := containsSignal(terminateSigs, os.Interrupt)
+	if ownsInterrupt {
+		sr.interruptWatchers.Add(1)
+	}
+
+	events := make(chan Event, 4)
+	emit := func(t EventType, sig os.Signal, err error) {
+		select {
+		case events <- Event{Type: t, Signal: sig, Err: err, Time: time.Now()}:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(events)
+		defer signal.Stop(sigCh)
+		if ownsInterrupt {
+			defer sr.interruptWatchers.Add(-1)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-sigCh:
+				switch {
+				case containsSignal(restartSigs, sig):
+					sr.logger.Log("info", fmt.Sprintf("Sinal %v recebido, reiniciando...", sig))
+					emit(EventRestarting, sig, nil)
+					if err := sr.Restart(opts.RestartOptions...); err != nil {
+						sr.logger.Log("error", fmt.Sprintf("Erro ao reiniciar: %v", err))
+						emit(EventRestartFailed, sig, err)
+						continue
+					}
+					emit(EventRestarted, sig, nil)
+					return
+				case containsSignal(reloadSigs, sig):
+					sr.logger.Log("info", fmt.Sprintf("Sinal %v recebido, recarregando...", sig))
+					var err error
+					if opts.OnReload != nil {
+						hookCtx, cancel := context.WithTimeout(ctx, sr.hookTimeout)
+						err = runHook(hookCtx, sr.logger, opts.OnReload)
+						cancel()
+					}
+					emit(EventReload, sig, err)
+				case containsSignal(terminateSigs, sig):
+					sr.logger.Log("info", fmt.Sprintf("Sinal %v recebido, encerrando...", sig))
+					emit(EventTerminating, sig, nil)
+					sr.notifySystemd(systemd.StateStopping)
+					hookCtx, cancel := context.WithTimeout(ctx, sr.hookTimeout)
+					err := sr.hooks.runShutdown(hookCtx, sr.logger)
+					cancel()
+					emit(EventTerminated, sig, err)
+					return
+				}
+			}
+		}
+	}()
+
+	return events
+}
+
+func signalsOrDefault(sigs, def []os.Signal) []os.Signal {
+	if len(sigs) > 0 {
+		return sigs
+	}
+	return def
+}
+
+func containsSignal(sigs []os.Signal, sig os.Signal) bool {
+	for _, s := range sigs {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package selfrestart
+
+import (
+	"os"
+)
+
+var (
+	defaultRestartSignals   []os.Signal
+	defaultReloadSignals    []os.Signal
+	defaultTerminateSignals = []os.Signal{os.Interrupt}
+)
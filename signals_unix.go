@@ -0,0 +1,14 @@
+//go:build unix
+
+package selfrestart
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	defaultRestartSignals   = []os.Signal{syscall.SIGUSR1}
+	defaultReloadSignals    = []os.Signal{syscall.SIGHUP}
+	defaultTerminateSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+)
@@ -0,0 +1,51 @@
+package selfrestart
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/systemd"
+	gl "github.com/rafa-mori/selfrestart/logger"
+)
+
+// UnderSystemd reports whether the process runs in a systemd unit that
+// listens for sd_notify messages (NOTIFY_SOCKET is set).
+func UnderSystemd() bool {
+	return systemd.NewNotifier().Enabled()
+}
+
+// NotifySystemd sends raw sd_notify states such as "STATUS=serving" to the
+// unit's notify socket. It is a no-op outside systemd.
+func NotifySystemd(states ...string) error {
+	n := systemd.NewNotifier()
+	if !n.Enabled() {
+		return nil
+	}
+	return n.Notify(states...)
+}
+
+// StartWatchdog sends WATCHDOG=1 keepalives at half the interval requested
+// with WatchdogSec= until ctx is done. It reports false, and starts nothing,
+// when systemd did not enable the watchdog for this process.
+func StartWatchdog(ctx context.Context) (time.Duration, bool) {
+	n := systemd.NewNotifier()
+	interval, ok := systemd.WatchdogInterval()
+	if !n.Enabled() || !ok {
+		return 0, false
+	}
+	go n.RunWatchdog(ctx, interval, func(err error) {
+		gl.Log("warn", fmt.Sprintf("Erro ao enviar keepalive ao systemd: %v", err))
+	})
+	return interval, true
+}
+
+// notifySystemd sends states when running under systemd and logs failures
+func (sr *SelfRestart) notifySystemd(states ...string) {
+	if !sr.notifier.Enabled() {
+		return
+	}
+	if err := sr.notifier.Notify(states...); err != nil {
+		sr.logger.Log("warn", fmt.Sprintf("Erro ao notificar o systemd: %v", err))
+	}
+}
@@ -0,0 +1,301 @@
+// Package updater downloads a release of the running program, verifies it and
+// swaps it in place of the current executable.
+package updater
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rafa-mori/selfrestart"
+	"github.com/rafa-mori/selfrestart/internal/platform"
+	gl "github.com/rafa-mori/selfrestart/logger"
+	vs "github.com/rafa-mori/selfrestart/version"
+)
+
+const (
+	// DefaultBaseURL serves the release assets of this repository.
+	DefaultBaseURL = "https://github.com/rafa-mori/selfrestart/releases/download"
+	// DefaultTagsURL is the API endpoint listing the repository tags.
+	DefaultTagsURL = "https://api.github.com/repos/rafa-mori/selfrestart"
+	// DefaultAsset names the release archive, as produced by the release workflow.
+	DefaultAsset = "{name}_{os}_{arch}.tar.gz"
+	// DefaultChecksum names the sha256sum file published next to the asset.
+	DefaultChecksum = "{asset}.sha256"
+	// DefaultSignature names the signature of the checksum file.
+	DefaultSignature = "{checksum}.minisig"
+)
+
+// Config describes where releases live and how they are verified.
+type Config struct {
+	// Name is the program name used in asset names. Defaults to "selfrestart".
+	Name string
+	// BaseURL is the prefix of asset URLs; assets are fetched from
+	// BaseURL/<version>/<asset>.
+	BaseURL string
+	// TagsURL is the repository API URL whose /tags endpoint lists versions.
+	TagsURL string
+	// Asset, Checksum and Signature are name templates. {name}, {os},
+	// {arch}, {version}, {asset} and {checksum} are expanded.
+	Asset     string
+	Checksum  string
+	Signature string
+	// PublicKey is a minisign or raw base64 ed25519 public key. When set, the
+	// checksum file must carry a valid signature. Minisign signatures must be
+	// made in legacy mode (minisign -S -l): the prehashed signatures minisign
+	// produces by default are rejected with ErrBadSignature.
+	PublicKey string
+	// Platform overrides the host platform used in asset names.
+	Platform *platform.PlatformInfo
+	// Client performs the downloads. Defaults to a client with a timeout.
+	Client *http.Client
+}
+
+// Release is a resolved release asset.
+type Release struct {
+	Version      string
+	AssetName    string
+	AssetURL     string
+	ChecksumURL  string
+	SignatureURL string
+}
+
+// Updater fetches and installs releases.
+type Updater struct {
+	cfg Config
+}
+
+func New(cfg Config) *Updater {
+	if cfg.Name == "" {
+		cfg.Name = "selfrestart"
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+	if cfg.TagsURL == "" {
+		cfg.TagsURL = DefaultTagsURL
+	}
+	if cfg.Asset == "" {
+		cfg.Asset = DefaultAsset
+	}
+	if cfg.Checksum == "" {
+		cfg.Checksum = DefaultChecksum
+	}
+	if cfg.Signature == "" {
+		cfg.Signature = DefaultSignature
+	}
+	if cfg.Platform == nil {
+		host := platform.GetHostPlatform()
+		cfg.Platform = &host
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &Updater{cfg: cfg}
+}
+
+// LatestVersion returns the highest tagged version. Pre-releases are only
+// considered when includePre is true. The tags are fetched with the
+// configured Client.
+func (u *Updater) LatestVersion(ctx context.Context, includePre bool) (string, error) {
+	tags, err := vs.ListTagsContext(ctx, u.cfg.Client, u.cfg.TagsURL)
+	if err != nil {
+		return "", fmt.Errorf("could not list versions: %v", err)
+	}
+	latest := ""
+	for _, tag := range tags {
+		if !includePre && vs.IsPrerelease(tag) {
+			continue
+		}
+		if latest == "" {
+			latest = tag
+			continue
+		}
+		if comp, err := vs.Compare(tag, latest); err == nil && comp > 0 {
+			latest = tag
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no matching version found")
+	}
+	return latest, nil
+}
+
+// Release resolves the asset URLs of version for the configured platform.
+func (u *Updater) Release(version string) Release {
+	r := strings.NewReplacer(
+		"{name}", u.cfg.Name,
+		"{os}", u.cfg.Platform.OS,
+		"{arch}", u.cfg.Platform.Arch,
+		"{version}", version,
+	)
+	asset := r.Replace(u.cfg.Asset)
+	checksum := strings.ReplaceAll(r.Replace(u.cfg.Checksum), "{asset}", asset)
+	signature := strings.ReplaceAll(strings.ReplaceAll(r.Replace(u.cfg.Signature), "{checksum}", checksum), "{asset}", asset)
+	base := strings.TrimSuffix(u.cfg.BaseURL, "/") + "/" + version + "/"
+	return Release{
+		Version:      version,
+		AssetName:    asset,
+		AssetURL:     base + asset,
+		ChecksumURL:  base + checksum,
+		SignatureURL: base + signature,
+	}
+}
+
+// Apply downloads rel, verifies it and atomically replaces target with the
+// new binary, keeping the file mode of target.
+func (u *Updater) Apply(ctx context.Context, rel Release, target string) error {
+	checksums, err := u.fetch(ctx, rel.ChecksumURL)
+	if err != nil {
+		return fmt.Errorf("could not download checksum file: %v", err)
+	}
+	if u.cfg.PublicKey != "" {
+		sig, err := u.fetch(ctx, rel.SignatureURL)
+		if err != nil {
+			return fmt.Errorf("could not download signature: %v", err)
+		}
+		if err := VerifySignature(u.cfg.PublicKey, checksums, sig); err != nil {
+			return err
+		}
+	}
+	want, err := ParseChecksum(checksums, rel.AssetName)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("could not stat %s: %v", target, err)
+	}
+
+	assetFile, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".download-*")
+	if err != nil {
+		return fmt.Errorf("could not create download file: %v", err)
+	}
+	defer func() {
+		_ = assetFile.Close()
+		_ = os.Remove(assetFile.Name())
+	}()
+	if err := u.download(ctx, rel.AssetURL, assetFile); err != nil {
+		return fmt.Errorf("could not download %s: %v", rel.AssetName, err)
+	}
+	if err := VerifyFile(assetFile.Name(), want); err != nil {
+		return err
+	}
+
+	if _, err := assetFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	binFile, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".new-*")
+	if err != nil {
+		return fmt.Errorf("could not create staging file: %v", err)
+	}
+	defer func() { _ = os.Remove(binFile.Name()) }()
+	if err := extract(assetFile, rel.AssetName, u.cfg.Name, binFile); err != nil {
+		_ = binFile.Close()
+		return err
+	}
+	if err := binFile.Chmod(info.Mode().Perm() | 0100); err != nil {
+		_ = binFile.Close()
+		return fmt.Errorf("could not set permissions: %v", err)
+	}
+	if err := binFile.Sync(); err != nil {
+		_ = binFile.Close()
+		return fmt.Errorf("could not flush new binary: %v", err)
+	}
+	if err := binFile.Close(); err != nil {
+		return fmt.Errorf("could not write new binary: %v", err)
+	}
+	if err := os.Rename(binFile.Name(), target); err != nil {
+		return fmt.Errorf("could not replace %s: %v", target, err)
+	}
+	gl.Log("success", fmt.Sprintf("Installed %s %s at %s", u.cfg.Name, rel.Version, target))
+	return nil
+}
+
+// Update installs version over the running executable and restarts through
+// sr. An empty version selects the latest stable release.
+func (u *Updater) Update(ctx context.Context, sr *selfrestart.SelfRestart, version string, opts ...selfrestart.RestartOption) error {
+	if version == "" {
+		latest, err := u.LatestVersion(ctx, false)
+		if err != nil {
+			return err
+		}
+		version = latest
+	}
+	target, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not locate running executable: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+	if err := u.Apply(ctx, u.Release(version), target); err != nil {
+		return err
+	}
+	return sr.Restart(opts...)
+}
+
+func (u *Updater) fetch(ctx context.Context, url string) ([]byte, error) {
+	var buf strings.Builder
+	if err := u.download(ctx, url, &buf); err != nil {
+		return nil, err
+	}
+	return []byte(buf.String()), nil
+}
+
+func (u *Updater) download(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := u.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// extract writes the binary contained in the asset to w. Plain binaries are
+// copied as is; for .tar.gz archives the first regular file whose name starts
+// with name is used.
+func extract(asset io.Reader, assetName, name string, w io.Writer) error {
+	if !strings.HasSuffix(assetName, ".tar.gz") && !strings.HasSuffix(assetName, ".tgz") {
+		_, err := io.Copy(w, asset)
+		return err
+	}
+	gz, err := gzip.NewReader(asset)
+	if err != nil {
+		return fmt.Errorf("could not open archive: %v", err)
+	}
+	defer func() { _ = gz.Close() }()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("no %s binary found in %s", name, assetName)
+		}
+		if err != nil {
+			return fmt.Errorf("could not read archive: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !strings.HasPrefix(filepath.Base(hdr.Name), name) {
+			continue
+		}
+		if _, err := io.Copy(w, tr); err != nil {
+			return fmt.Errorf("could not extract %s: %v", hdr.Name, err)
+		}
+		return nil
+	}
+}
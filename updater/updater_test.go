@@ -0,0 +1,368 @@
+package updater
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rafa-mori/selfrestart/internal/platform"
+)
+
+const (
+	testVersion = "v1.2.0"
+	testAsset   = "selfrestart_linux_amd64.tar.gz"
+)
+
+var newBinary = []byte("#!/bin/sh\necho new\n")
+
+// testKey is a minisign key pair.
+type testKey struct {
+	id   []byte
+	pub  ed25519.PublicKey
+	priv ed25519.PrivateKey
+}
+
+func newTestKey(t *testing.T) testKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatal(err)
+	}
+	return testKey{id: id, pub: pub, priv: priv}
+}
+
+// public returns the key as written in a minisign .pub file.
+func (k testKey) public() string {
+	blob := append(append([]byte("Ed"), k.id...), k.pub...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(blob) + "\n"
+}
+
+// sign returns a minisign signature file over message, as made by minisign -S -l.
+func (k testKey) sign(message []byte) []byte {
+	return k.signAs("Ed", message)
+}
+
+// signAs is sign with the algorithm of the signature blob set to alg.
+func (k testKey) signAs(alg string, message []byte) []byte {
+	sig := ed25519.Sign(k.priv, message)
+	blob := append(append([]byte(alg), k.id...), sig...)
+	trusted := "timestamp:1700000000\tfile:" + testAsset + ".sha256"
+	global := ed25519.Sign(k.priv, append(append([]byte(nil), sig...), trusted...))
+	return []byte(fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(blob), trusted, base64.StdEncoding.EncodeToString(global)))
+}
+
+func archive(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sha256sum(data []byte, name string) []byte {
+	sum := sha256.Sum256(data)
+	return []byte(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+}
+
+// releaseServer serves files by path and counts the requests it received.
+type releaseServer struct {
+	*httptest.Server
+	files    map[string][]byte
+	requests atomic.Int32
+}
+
+func newReleaseServer(t *testing.T, files map[string][]byte) *releaseServer {
+	t.Helper()
+	s := &releaseServer{files: files}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		data, ok := s.files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// release returns the files of a good signed release.
+func release(t *testing.T, key testKey) map[string][]byte {
+	asset := archive(t, map[string][]byte{"README.md": []byte("docs"), "selfrestart": newBinary})
+	checksum := sha256sum(asset, testAsset)
+	dir := "/" + testVersion + "/"
+	return map[string][]byte{
+		dir + testAsset:                     asset,
+		dir + testAsset + ".sha256":         checksum,
+		dir + testAsset + ".sha256.minisig": key.sign(checksum),
+	}
+}
+
+func newTestUpdater(s *releaseServer, publicKey string) *Updater {
+	return New(Config{
+		BaseURL:   s.URL,
+		TagsURL:   s.URL + "/repo",
+		PublicKey: publicKey,
+		Platform:  &platform.PlatformInfo{OS: "linux", Arch: "amd64"},
+		Client:    s.Client(),
+	})
+}
+
+// target writes the binary being replaced and returns its path.
+func target(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "selfrestart")
+	if err := os.WriteFile(path, []byte("old"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// assertTarget checks the content of path and that no staging file was left
+// next to it.
+func assertTarget(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("target holds %q, want %q", got, want)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Fatalf("leftover files next to target: %v", names)
+	}
+}
+
+func TestApply(t *testing.T) {
+	key := newTestKey(t)
+	s := newReleaseServer(t, release(t, key))
+	u := newTestUpdater(s, key.public())
+	path := target(t)
+
+	if err := u.Apply(context.Background(), u.Release(testVersion), path); err != nil {
+		t.Fatal(err)
+	}
+	assertTarget(t, path, newBinary)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o750 {
+		t.Errorf("mode = %v, want 0750", info.Mode().Perm())
+	}
+}
+
+func TestApplyWithoutPublicKeySkipsSignature(t *testing.T) {
+	key := newTestKey(t)
+	files := release(t, key)
+	delete(files, "/"+testVersion+"/"+testAsset+".sha256.minisig")
+	s := newReleaseServer(t, files)
+	u := newTestUpdater(s, "")
+	path := target(t)
+
+	if err := u.Apply(context.Background(), u.Release(testVersion), path); err != nil {
+		t.Fatal(err)
+	}
+	assertTarget(t, path, newBinary)
+}
+
+func TestApplyChecksumMismatch(t *testing.T) {
+	key := newTestKey(t)
+	files := release(t, key)
+	checksum := sha256sum([]byte("something else"), testAsset)
+	files["/"+testVersion+"/"+testAsset+".sha256"] = checksum
+	files["/"+testVersion+"/"+testAsset+".sha256.minisig"] = key.sign(checksum)
+	s := newReleaseServer(t, files)
+	u := newTestUpdater(s, key.public())
+	path := target(t)
+
+	err := u.Apply(context.Background(), u.Release(testVersion), path)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Apply = %v, want ErrChecksumMismatch", err)
+	}
+	assertTarget(t, path, []byte("old"))
+}
+
+func TestApplyBadSignature(t *testing.T) {
+	key := newTestKey(t)
+	other := newTestKey(t)
+	files := release(t, key)
+	checksum := files["/"+testVersion+"/"+testAsset+".sha256"]
+
+	tests := []struct {
+		name string
+		sig  []byte
+	}{
+		{"other key", other.sign(checksum)},
+		{"other message", key.sign([]byte("tampered"))},
+		{"garbage", []byte("not a signature")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files["/"+testVersion+"/"+testAsset+".sha256.minisig"] = tt.sig
+			s := newReleaseServer(t, files)
+			u := newTestUpdater(s, key.public())
+			path := target(t)
+
+			err := u.Apply(context.Background(), u.Release(testVersion), path)
+			if !errors.Is(err, ErrBadSignature) {
+				t.Fatalf("Apply = %v, want ErrBadSignature", err)
+			}
+			assertTarget(t, path, []byte("old"))
+		})
+	}
+}
+
+func TestVerifySignatureRejectsPrehashed(t *testing.T) {
+	key := newTestKey(t)
+	message := []byte("checksum")
+	if err := VerifySignature(key.public(), message, key.sign(message)); err != nil {
+		t.Fatalf("legacy signature: %v", err)
+	}
+
+	// minisign signs with "ED" and a BLAKE2b-512 prehash unless given -l
+	err := VerifySignature(key.public(), message, key.signAs("ED", message))
+	if !errors.Is(err, ErrBadSignature) || !strings.Contains(err.Error(), "minisign -S -l") {
+		t.Fatalf("VerifySignature of a prehashed signature = %v, want ErrBadSignature pointing to -l", err)
+	}
+}
+
+func TestApplyMissingSignature(t *testing.T) {
+	key := newTestKey(t)
+	files := release(t, key)
+	delete(files, "/"+testVersion+"/"+testAsset+".sha256.minisig")
+	s := newReleaseServer(t, files)
+	u := newTestUpdater(s, key.public())
+	path := target(t)
+
+	if err := u.Apply(context.Background(), u.Release(testVersion), path); err == nil {
+		t.Fatal("Apply without a signature succeeded")
+	}
+	assertTarget(t, path, []byte("old"))
+}
+
+func TestApplyArchiveWithoutBinary(t *testing.T) {
+	asset := archive(t, map[string][]byte{"README.md": []byte("docs")})
+	s := newReleaseServer(t, map[string][]byte{
+		"/" + testVersion + "/" + testAsset:             asset,
+		"/" + testVersion + "/" + testAsset + ".sha256": sha256sum(asset, testAsset),
+	})
+	u := newTestUpdater(s, "")
+	path := target(t)
+
+	if err := u.Apply(context.Background(), u.Release(testVersion), path); err == nil {
+		t.Fatal("Apply of an archive without the binary succeeded")
+	}
+	assertTarget(t, path, []byte("old"))
+}
+
+func TestLatestVersion(t *testing.T) {
+	s := newReleaseServer(t, map[string][]byte{
+		"/repo/tags": []byte(`[{"name":"v1.10.0-rc.1"},{"name":"v1.9.0"},{"name":"v1.10.0-beta"},{"name":"v1.2.0"}]`),
+	})
+	u := newTestUpdater(s, "")
+
+	latest, err := u.LatestVersion(context.Background(), false)
+	if err != nil || latest != "v1.9.0" {
+		t.Fatalf("LatestVersion(stable) = %q, %v; want v1.9.0", latest, err)
+	}
+	latest, err = u.LatestVersion(context.Background(), true)
+	if err != nil || latest != "v1.10.0-rc.1" {
+		t.Fatalf("LatestVersion(pre) = %q, %v; want v1.10.0-rc.1", latest, err)
+	}
+	if got := s.requests.Load(); got != 2 {
+		t.Fatalf("server got %d requests, want 2", got)
+	}
+}
+
+func TestLatestVersionUsesClientAndContext(t *testing.T) {
+	s := newReleaseServer(t, map[string][]byte{"/repo/tags": []byte(`[{"name":"v1.0.0"}]`)})
+	u := newTestUpdater(s, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := u.LatestVersion(ctx, false); err == nil {
+		t.Fatal("LatestVersion with a canceled context succeeded")
+	}
+
+	var used atomic.Bool
+	u.cfg.Client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		used.Store(true)
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	if _, err := u.LatestVersion(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	if !used.Load() {
+		t.Fatal("LatestVersion did not use the configured client")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestRelease(t *testing.T) {
+	u := New(Config{
+		Name:     "mysvc",
+		BaseURL:  "https://example.com/dl/",
+		Asset:    "{name}-{version}-{os}-{arch}",
+		Platform: &platform.PlatformInfo{OS: "darwin", Arch: "arm64"},
+	})
+	want := Release{
+		Version:      "v2.0.0",
+		AssetName:    "mysvc-v2.0.0-darwin-arm64",
+		AssetURL:     "https://example.com/dl/v2.0.0/mysvc-v2.0.0-darwin-arm64",
+		ChecksumURL:  "https://example.com/dl/v2.0.0/mysvc-v2.0.0-darwin-arm64.sha256",
+		SignatureURL: "https://example.com/dl/v2.0.0/mysvc-v2.0.0-darwin-arm64.sha256.minisig",
+	}
+	if got := u.Release("v2.0.0"); got != want {
+		t.Fatalf("Release =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+package updater
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrChecksumMismatch is returned when a download does not match its checksum.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrBadSignature is returned when the checksum file signature is invalid.
+	ErrBadSignature = errors.New("invalid signature")
+)
+
+// ParseChecksum finds the SHA-256 of assetName in a sha256sum-style file. A
+// file holding a single bare hash is accepted as well.
+func ParseChecksum(data []byte, assetName string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 1 && len(lines) == 1:
+			return normalizeHash(fields[0])
+		case len(fields) >= 2:
+			name := strings.TrimPrefix(fields[1], "*")
+			if filepath.Base(name) == assetName {
+				return normalizeHash(fields[0])
+			}
+		}
+	}
+	return "", fmt.Errorf("no checksum for %s", assetName)
+}
+
+func normalizeHash(h string) (string, error) {
+	h = strings.ToLower(h)
+	if b, err := hex.DecodeString(h); err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("malformed SHA-256 checksum: %q", h)
+	}
+	return h, nil
+}
+
+// VerifyFile checks that the SHA-256 of the file at path is want.
+func VerifyFile(path, want string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("could not hash %s: %v", path, err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, want, got)
+	}
+	return nil
+}
+
+// VerifySignature checks sig over message with publicKey. The key is either a
+// minisign public key or a base64 raw ed25519 key; the signature is either a
+// minisign signature file or a raw or base64 ed25519 signature. Prehashed
+// minisign signatures, the minisign default, are not supported; sign with
+// minisign -S -l.
+func VerifySignature(publicKey string, message, sig []byte) error {
+	pub, keyID, err := parsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(sig, []byte("untrusted comment:")) {
+		return verifyMinisign(pub, keyID, message, sig)
+	}
+	raw := sig
+	if len(raw) != ed25519.SignatureSize {
+		if raw, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err != nil {
+			return fmt.Errorf("%w: malformed signature", ErrBadSignature)
+		}
+	}
+	if len(raw) != ed25519.SignatureSize || !ed25519.Verify(pub, message, raw) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// parsePublicKey accepts the last line of a minisign .pub file (algorithm,
+// key id and key) or a bare base64 ed25519 key.
+func parsePublicKey(publicKey string) (ed25519.PublicKey, []byte, error) {
+	lines := strings.Split(strings.TrimSpace(publicKey), "\n")
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed public key: %v", err)
+	}
+	switch len(raw) {
+	case ed25519.PublicKeySize:
+		return ed25519.PublicKey(raw), nil, nil
+	case 2 + 8 + ed25519.PublicKeySize:
+		if string(raw[:2]) != "Ed" {
+			return nil, nil, fmt.Errorf("unsupported public key algorithm %q", raw[:2])
+		}
+		return ed25519.PublicKey(raw[10:]), raw[2:10], nil
+	default:
+		return nil, nil, fmt.Errorf("malformed public key: unexpected length %d", len(raw))
+	}
+}
+
+// verifyMinisign checks a minisign signature file: the signature over the
+// message and the global signature over the trusted comment.
+func verifyMinisign(pub ed25519.PublicKey, keyID, message, file []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(file), "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return fmt.Errorf("%w: truncated minisign file", ErrBadSignature)
+	}
+	sigBlob, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sigBlob) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed minisign signature", ErrBadSignature)
+	}
+	switch string(sigBlob[:2]) {
+	case "Ed":
+	case "ED":
+		return fmt.Errorf("%w: prehashed minisign signatures (the minisign default) are not supported, sign with minisign -S -l", ErrBadSignature)
+	default:
+		return fmt.Errorf("%w: unknown minisign algorithm %q", ErrBadSignature, sigBlob[:2])
+	}
+	if keyID != nil && !bytes.Equal(keyID, sigBlob[2:10]) {
+		return fmt.Errorf("%w: signed with a different key", ErrBadSignature)
+	}
+	signature := sigBlob[10:]
+	if !ed25519.Verify(pub, message, signature) {
+		return ErrBadSignature
+	}
+
+	trusted, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !ok {
+		return fmt.Errorf("%w: missing trusted comment", ErrBadSignature)
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed global signature", ErrBadSignature)
+	}
+	if !ed25519.Verify(pub, append(append([]byte(nil), signature...), trusted...), global) {
+		return fmt.Errorf("%w: trusted comment does not match", ErrBadSignature)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package selfrestart
+
+import (
+	"os"
+
+	"github.com/rafa-mori/selfrestart/internal/restart"
+)
+
+// ProbeFlag is passed to the candidate binary by the default validation.
+const ProbeFlag = restart.ProbeFlag
+
+// Validation configures the check run on the binary before restarting. Use
+// "{bin}" in Command to refer to the candidate binary path.
+type Validation = restart.Validation
+
+// ValidationError is returned by Restart when the candidate binary failed
+// validation. Use errors.As to inspect it.
+type ValidationError = restart.ValidationError
+
+// WithValidation runs v against the binary before the current process is
+// told to exit. A failure aborts the restart with a *ValidationError.
+func WithValidation(v Validation) RestartOption {
+	return func(cfg *restartConfig) {
+		cfg.validation = &v
+	}
+}
+
+// WithProbe validates the binary by running it with ProbeFlag.
+func WithProbe() RestartOption {
+	return WithValidation(Validation{})
+}
+
+// IsProbe reports whether the process was started by a restart validation.
+// Applications should finish initializing and exit 0 when it returns true.
+func IsProbe() bool {
+	for _, arg := range os.Args[1:] {
+		if arg == ProbeFlag {
+			return true
+		}
+	}
+	return false
+}
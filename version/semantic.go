@@ -9,6 +9,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
@@ -64,29 +65,52 @@ func init() {
 }
 
 func getLatestTag(repoURL string) (string, error) {
-	apiURL := fmt.Sprintf("%s/tags", repoURL)
-	resp, err := http.Get(apiURL)
+	tags, err := ListTags(repoURL)
 	if err != nil {
 		return "", err
 	}
+	return tags[0], nil
+}
+
+// ListTags returns the tag names served by repoURL/tags, in the order the
+// API returns them.
+func ListTags(repoURL string) ([]string, error) {
+	return ListTagsContext(context.Background(), http.DefaultClient, repoURL)
+}
+
+// ListTagsContext is ListTags with the request made by client and bound to ctx.
+func ListTagsContext(ctx context.Context, client *http.Client, repoURL string) ([]string, error) {
+	apiURL := fmt.Sprintf("%s/tags", strings.TrimSuffix(repoURL, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to fetch tags: %s", resp.Status)
+		return nil, fmt.Errorf("failed to fetch tags: %s", resp.Status)
 	}
 
 	var tags []Tag
 	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if len(tags) == 0 {
-		return "", fmt.Errorf("no tags found")
+		return nil, fmt.Errorf("no tags found")
 	}
 
-	return tags[0].Name, nil
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names, nil
 }
 
 func (v *ServiceImpl) updateLatestVersion() error {
@@ -125,7 +149,11 @@ func (v *ServiceImpl) versionAtMost(versionAtMostArg, max []int) (bool, error) {
 }
 func (v *ServiceImpl) parseVersion(versionToParse string) []int {
 	version := make([]int, 3)
-	for idx, vStr := range strings.Split(versionToParse, ".") {
+	parts := strings.Split(versionToParse, ".")
+	if len(parts) > len(version) {
+		return nil
+	}
+	for idx, vStr := range parts {
 		vS, err := strconv.Atoi(vStr)
 		if err != nil {
 			return nil
@@ -135,6 +163,60 @@ func (v *ServiceImpl) parseVersion(versionToParse string) []int {
 	return version
 }
 
+// Compare compares two versions such as "v1.2.3", "1.24" or "go1.24.4" and
+// returns -1, 0 or 1. A pre-release ("1.2.3-rc1") sorts before its release.
+func Compare(a, b string) (int, error) {
+	v := &ServiceImpl{}
+	coreA, preA := splitVersion(a)
+	coreB, preB := splitVersion(b)
+	parsedA, parsedB := v.parseVersion(coreA), v.parseVersion(coreB)
+	if parsedA == nil {
+		return 0, fmt.Errorf("invalid version: %q", a)
+	}
+	if parsedB == nil {
+		return 0, fmt.Errorf("invalid version: %q", b)
+	}
+	comp, err := v.vrsCompare(parsedA, parsedB)
+	if err != nil || comp != 0 {
+		return comp, err
+	}
+	switch {
+	case preA == preB:
+		return 0, nil
+	case preA == "":
+		return 1, nil
+	case preB == "":
+		return -1, nil
+	case preA < preB:
+		return -1, nil
+	default:
+		return 1, nil
+	}
+}
+
+// IsPrerelease reports whether version carries a pre-release suffix.
+func IsPrerelease(version string) bool {
+	_, pre := splitVersion(version)
+	return pre != ""
+}
+
+// splitVersion strips the "v" or "go" prefix and build metadata from version
+// and splits it into its numeric core and pre-release suffix.
+func splitVersion(version string) (string, string) {
+	version = strings.TrimSpace(version)
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "go"), "v")
+	version, _, _ = strings.Cut(version, "+")
+	core, pre, _ := strings.Cut(version, "-")
+	// Go toolchains spell pre-releases without a dash, as in "1.24rc1"
+	if idx := strings.IndexFunc(core, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); idx > 0 {
+		core, pre = core[:idx], core[idx:]
+	}
+	if parts := strings.Split(core, "."); len(parts) > 3 {
+		core = strings.Join(parts[:3], ".")
+	}
+	return core, pre
+}
+
 func (v *ServiceImpl) IsLatestVersion() (bool, error) {
 	if v.latestVersion == "" {
 		if err := v.updateLatestVersion(); err != nil {
@@ -0,0 +1,80 @@
+package selfrestart
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rafa-mori/selfrestart/internal/watch"
+)
+
+// EventBinaryChanged is sent by WatchBinary when the executable was replaced
+// and is stable.
+const EventBinaryChanged EventType = "binary_changed"
+
+// BinaryWatchOptions configures WatchBinary.
+type BinaryWatchOptions struct {
+	// StableFor is how long the new binary must stay untouched before it is
+	// used. Defaults to two seconds.
+	StableFor time.Duration
+	// Verify, when set, checks the new binary before restarting. An error
+	// skips that version and the watcher keeps running.
+	Verify func(ctx context.Context, path string) error
+	// RestartOptions are passed to the triggered Restart.
+	RestartOptions []RestartOption
+}
+
+// WatchBinary watches the current executable and calls Restart once it has
+// been replaced or rewritten, is stable and executable, and passed Verify.
+// Events are reported on the returned channel, which is closed after
+// EventRestarted or when ctx is done.
+func (sr *SelfRestart) WatchBinary(ctx context.Context, opts BinaryWatchOptions) (<-chan Event, error) {
+	binPath, err := sr.getCurrentBinaryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := watch.NewBinaryWatcher(binPath, opts.StableFor)
+	changed := make(chan string)
+	events := make(chan Event, 4)
+	emit := func(t EventType, err error) {
+		select {
+		case events <- Event{Type: t, Err: err, Time: time.Now()}:
+		case <-ctx.Done():
+		}
+	}
+
+	watchCtx, stop := context.WithCancel(ctx)
+	go func() {
+		if err := watcher.Run(watchCtx, changed); err != nil {
+			sr.logger.Log("error", fmt.Sprintf("Erro ao observar o binário %s: %v", binPath, err))
+		}
+		close(changed)
+	}()
+
+	go func() {
+		defer close(events)
+		defer stop()
+		for path := range changed {
+			sr.logger.Log("info", fmt.Sprintf("Binário %s foi substituído", path))
+			emit(EventBinaryChanged, nil)
+			if opts.Verify != nil {
+				if err := opts.Verify(ctx, path); err != nil {
+					sr.logger.Log("error", fmt.Sprintf("Novo binário rejeitado: %v", err))
+					emit(EventRestartFailed, err)
+					continue
+				}
+			}
+			emit(EventRestarting, nil)
+			if err := sr.Restart(opts.RestartOptions...); err != nil {
+				sr.logger.Log("error", fmt.Sprintf("Erro ao reiniciar: %v", err))
+				emit(EventRestartFailed, err)
+				continue
+			}
+			emit(EventRestarted, nil)
+			return
+		}
+	}()
+
+	return events, nil
+}